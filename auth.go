@@ -0,0 +1,342 @@
+package httpc
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// HeaderAuthorization 认证信息
+	HeaderAuthorization = "Authorization"
+	// HeaderWWWAuthenticate 认证质询
+	HeaderWWWAuthenticate = "WWW-Authenticate"
+)
+
+// Authenticator 认证方式 包装实际发送请求的处理函数（在中间件内层执行）
+// 可以在请求前添加凭证，也可以在收到质询后重新发送请求（通过 req.GetBody 获取 body）
+type Authenticator interface {
+	Authenticate(next Handler) Handler
+}
+
+// bodyReplayer 需要重新发送请求的认证方式（body 会被缓存以便重复发送）
+type bodyReplayer interface {
+	replayBody() bool
+}
+
+// AuthenticatorFunc 函数形式的 Authenticator
+type AuthenticatorFunc func(next Handler) Handler
+
+// Authenticate 实现 Authenticator
+func (f AuthenticatorFunc) Authenticate(next Handler) Handler {
+	return f(next)
+}
+
+// SetAuth 设置认证方式（同一时间只有一种认证方式生效）
+func (c Client) SetAuth(auth Authenticator) *Client {
+	c.Auth = auth
+	return &c
+}
+
+// DeleteAuth 删除认证方式
+func (c Client) DeleteAuth() *Client {
+	c.Auth = nil
+	return &c
+}
+
+// SetBasicAuth 使用 Basic 认证
+func (c Client) SetBasicAuth(username, password string) *Client {
+	return c.SetAuth(AuthenticatorFunc(func(next Handler) Handler {
+		return func(req *http.Request) (*Response, error) {
+			req.SetBasicAuth(username, password)
+			return next(req)
+		}
+	}))
+}
+
+// SetBearerToken 使用 Bearer 认证
+func (c Client) SetBearerToken(token string) *Client {
+	return c.SetAuth(AuthenticatorFunc(func(next Handler) Handler {
+		return func(req *http.Request) (*Response, error) {
+			req.Header.Set(HeaderAuthorization, "Bearer "+token)
+			return next(req)
+		}
+	}))
+}
+
+// SetDigestAuth 使用 Digest 认证（支持 MD5 SHA-256 及其 -sess 算法 qop=auth）
+// 收到 401 质询后会自动计算并重新发送请求，之后的请求会复用质询信息
+func (c Client) SetDigestAuth(username, password string) *Client {
+	return c.SetAuth(&digestAuth{username: username, password: password})
+}
+
+// digestAuth Digest 认证
+type digestAuth struct {
+	username string
+	password string
+
+	mu        sync.Mutex
+	challenge *digestChallenge // 上次收到的质询
+	nc        int              // 使用当前 nonce 的次数
+}
+
+// digestChallenge Digest 质询
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string // 空则为 RFC 2069 的旧格式
+}
+
+func (d *digestAuth) replayBody() bool {
+	return true
+}
+
+// Authenticate 实现 Authenticator
+func (d *digestAuth) Authenticate(next Handler) Handler {
+	return func(req *http.Request) (*Response, error) {
+		// 已有质询信息时直接携带认证信息
+		d.mu.Lock()
+		challenge := d.challenge
+		d.mu.Unlock()
+		if challenge != nil {
+			if err := d.authorize(req, challenge); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := next(req)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+
+		challenge, err = parseDigestChallenge(resp.Header.Values(HeaderWWWAuthenticate))
+		if err != nil {
+			return resp, nil // 不是 Digest 质询 交由调用方处理
+		}
+
+		retry, ok := cloneRequest(req)
+		if !ok {
+			return resp, nil // body 无法重复读取 交由调用方处理
+		}
+		discardBody(resp.Body)
+
+		d.mu.Lock()
+		d.challenge, d.nc = challenge, 0
+		d.mu.Unlock()
+
+		if err := d.authorize(retry, challenge); err != nil {
+			return nil, err
+		}
+
+		return next(retry)
+	}
+}
+
+// authorize 计算并设置认证信息
+func (d *digestAuth) authorize(req *http.Request, challenge *digestChallenge) error {
+	algorithm := strings.ToUpper(challenge.algorithm)
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return fmt.Errorf("unsupported digest algorithm: %q", challenge.algorithm)
+	}
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	d.mu.Lock()
+	d.nc++
+	nc := fmt.Sprintf("%08x", d.nc)
+	d.mu.Unlock()
+
+	cnonce, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+
+	uri := req.URL.RequestURI()
+	ha1 := h(d.username + ":" + challenge.realm + ":" + d.password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + challenge.nonce + ":" + cnonce)
+	}
+	ha2 := h(req.Method + ":" + uri)
+
+	var response string
+	if challenge.qop == "" {
+		response = h(ha1 + ":" + challenge.nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + challenge.nonce + ":" + nc + ":" + cnonce + ":" + challenge.qop + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, quoteEscaper.Replace(d.username)),
+		fmt.Sprintf(`realm="%s"`, quoteEscaper.Replace(challenge.realm)),
+		fmt.Sprintf(`nonce="%s"`, quoteEscaper.Replace(challenge.nonce)),
+		fmt.Sprintf(`uri="%s"`, quoteEscaper.Replace(uri)),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if challenge.algorithm != "" {
+		parts = append(parts, "algorithm="+challenge.algorithm)
+	}
+	if challenge.qop != "" {
+		parts = append(parts, "qop="+challenge.qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if challenge.opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, quoteEscaper.Replace(challenge.opaque)))
+	}
+
+	req.Header.Set(HeaderAuthorization, "Digest "+strings.Join(parts, ", "))
+	return nil
+}
+
+// parseDigestChallenge 解析 WWW-Authenticate 中的 Digest 质询（同时存在多个时优先使用 SHA-256）
+func parseDigestChallenge(headers []string) (*digestChallenge, error) {
+	var found *digestChallenge
+	for _, header := range headers {
+		for _, v := range splitChallenges(header) {
+			if len(v) < 7 || !strings.EqualFold(v[:7], "Digest ") {
+				continue
+			}
+
+			params := parseAuthParams(v[7:])
+			challenge := &digestChallenge{
+				realm:     params["realm"],
+				nonce:     params["nonce"],
+				opaque:    params["opaque"],
+				algorithm: params["algorithm"],
+			}
+			if qop, ok := params["qop"]; ok {
+				for _, q := range strings.Split(qop, ",") {
+					if strings.TrimSpace(q) == "auth" {
+						challenge.qop = "auth"
+					}
+				}
+				if challenge.qop == "" {
+					continue // 仅支持 auth-int 的质询不处理
+				}
+			}
+
+			if found == nil || strings.HasPrefix(strings.ToUpper(challenge.algorithm), "SHA-256") {
+				found = challenge
+			}
+		}
+	}
+
+	if found == nil || found.nonce == "" {
+		return nil, errors.New("no supported digest challenge")
+	}
+
+	return found, nil
+}
+
+// splitChallenges 按认证方式拆分 WWW-Authenticate 如 `Basic realm="a", Digest realm="b", nonce="c"`
+func splitChallenges(header string) []string {
+	var out []string
+	var current strings.Builder
+	for _, part := range splitAuthParams(header) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		// 新的认证方式以 token 开头且后面不是 =
+		if i := strings.IndexAny(part, " ="); i > 0 && part[i] == ' ' {
+			if current.Len() != 0 {
+				out = append(out, current.String())
+				current.Reset()
+			}
+		} else if current.Len() != 0 {
+			current.WriteString(", ")
+		}
+		current.WriteString(part)
+	}
+	if current.Len() != 0 {
+		out = append(out, current.String())
+	}
+
+	return out
+}
+
+// parseAuthParams 解析 key=value 或 key="value" 形式的参数
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for _, v := range splitAuthParams(s) {
+		i := strings.IndexByte(v, '=')
+		if i < 0 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(v[:i]))
+		value := strings.TrimSpace(v[i+1:])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+		}
+		params[key] = value
+	}
+
+	return params
+}
+
+// splitAuthParams 按逗号拆分（忽略引号中的逗号）
+func splitAuthParams(s string) []string {
+	var out []string
+	quoted, escaped, start := false, false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\' && quoted:
+			escaped = true
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == ',' && !quoted:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(out, s[start:])
+}
+
+// cloneRequest 复制请求以便重新发送 body 无法重复读取时返回 false
+func cloneRequest(req *http.Request) (*http.Request, bool) {
+	out := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return out, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	out.Body = body
+
+	return out, true
+}
+
+// randomHex 生成随机 hex 字符串
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
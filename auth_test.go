@@ -0,0 +1,86 @@
+package httpc
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetBasicAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(HeaderAuthorization)))
+	}))
+	defer srv.Close()
+
+	res, err := SetBasicAuth("niconiconi", "123456").Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text() != "Basic bmljb25pY29uaToxMjM0NTY=" {
+		t.Error("Basic 认证信息错误")
+	}
+
+	res, err = SetBasicAuth("niconiconi", "123456").SetBearerToken("token").Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text() != "Bearer token" {
+		t.Error("Bearer 认证信息错误")
+	}
+}
+
+func TestSetDigestAuth(t *testing.T) {
+	for _, algorithm := range []string{"MD5", "SHA-256"} {
+		newHash := md5.New
+		if algorithm == "SHA-256" {
+			newHash = sha256.New
+		}
+		h := func(s string) string {
+			var hh hash.Hash = newHash()
+			hh.Write([]byte(s))
+			return hex.EncodeToString(hh.Sum(nil))
+		}
+
+		var count int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count++
+			b, _ := ioutil.ReadAll(r.Body)
+			if string(b) != "niconiconi" {
+				t.Errorf("body 未重新发送: %q", b)
+			}
+
+			auth := r.Header.Get(HeaderAuthorization)
+			if !strings.HasPrefix(auth, "Digest ") {
+				w.Header().Add(HeaderWWWAuthenticate, `Basic realm="test"`)
+				w.Header().Add(HeaderWWWAuthenticate, `Digest realm="test", qop="auth,auth-int", nonce="abc", opaque="xyz", algorithm=`+algorithm)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			p := parseAuthParams(auth[7:])
+			ha1 := h("niconiconi:test:123456")
+			ha2 := h(r.Method + ":" + p["uri"])
+			if p["response"] != h(ha1+":abc:"+p["nc"]+":"+p["cnonce"]+":auth:"+ha2) || p["opaque"] != "xyz" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+
+		client := SetDigestAuth("niconiconi", "123456").SetBody(ioutil.NopCloser(strings.NewReader("niconiconi")))
+		res, err := client.Post(srv.URL + "/digest?name=elissa")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Text() != "ok" || count != 2 {
+			t.Errorf("%s 认证失败", algorithm)
+		}
+		srv.Close()
+	}
+}
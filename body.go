@@ -0,0 +1,131 @@
+package httpc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+var errNotReplayable = errors.New("request body can not be read again")
+
+// requestBody 可以重复打开的请求 body
+type requestBody struct {
+	size       int64                     // 长度（-1 为未知）
+	replayable bool                      // 是否可以重复读取
+	open       func() (io.Reader, error) // 获取一个新的读取器
+	release    func()                    // 释放尚未使用的资源（如提前打开的文件 可以为空）
+}
+
+// sizedReaderAt bytes.Reader 与 strings.Reader 都实现了该接口
+type sizedReaderAt interface {
+	io.ReaderAt
+	Len() int
+	Size() int64
+}
+
+// bodyOpener 自行控制读取方式的 body（如流式表单）
+type bodyOpener interface {
+	io.Reader
+	newBody() (*requestBody, error)
+}
+
+// newRequestBody 将 body 包装成可重复读取的形式
+// buffered 为 true 时一次性的 io.Reader 会被读取到内存中以便重复发送
+func newRequestBody(body io.Reader, buffered bool) (*requestBody, error) {
+	switch value := body.(type) {
+	case bodyOpener:
+		rb, err := value.newBody()
+		if err != nil || rb.replayable || !buffered {
+			return rb, err
+		}
+		reader, err := rb.open()
+		if err != nil {
+			return nil, err
+		}
+		return newRequestBody(reader, buffered)
+	case nil:
+		return &requestBody{replayable: true, open: func() (io.Reader, error) { return nil, nil }}, nil
+	case sizedReaderAt:
+		// 按偏移读取不会改变原始读取器的位置，所以同一个 Client 可以多次发送
+		offset, n := value.Size()-int64(value.Len()), int64(value.Len())
+		return &requestBody{size: n, replayable: true, open: func() (io.Reader, error) {
+			return io.NewSectionReader(value, offset, n), nil
+		}}, nil
+	case *bytes.Buffer:
+		b := value.Bytes()
+		return &requestBody{size: int64(len(b)), replayable: true, open: func() (io.Reader, error) {
+			return bytes.NewReader(b), nil
+		}}, nil
+	}
+
+	if !buffered {
+		used := false
+		return &requestBody{size: -1, open: func() (io.Reader, error) {
+			if used {
+				return nil, errNotReplayable
+			}
+			used = true
+			return body, nil
+		}}, nil
+	}
+
+	b, err := ioutil.ReadAll(body)
+	if closer, ok := body.(io.Closer); ok { // 与 http.Client 的行为保持一致，读完后关闭
+		closer.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newRequestBody(bytes.NewReader(b), buffered)
+}
+
+// close 请求结束后释放尚未使用的资源
+func (b *requestBody) close() {
+	if b.release != nil {
+		b.release()
+	}
+}
+
+// apply 将 body 设置到请求上
+func (b *requestBody) apply(req *http.Request) error {
+	reader, err := b.open()
+	if err != nil {
+		return err
+	}
+	if reader == nil {
+		return nil
+	}
+
+	rc, ok := reader.(io.ReadCloser)
+	if !ok {
+		rc = ioutil.NopCloser(reader)
+	}
+	req.Body = rc
+	req.ContentLength = b.size
+	if b.size < 0 {
+		req.ContentLength = 0 // 0 且 Body 不为空时为未知长度
+	} else if b.size == 0 {
+		req.Body = http.NoBody
+	}
+
+	if b.replayable {
+		req.GetBody = func() (io.ReadCloser, error) {
+			reader, err := b.open()
+			if err != nil {
+				return nil, err
+			}
+			if reader == nil {
+				return http.NoBody, nil
+			}
+			if rc, ok := reader.(io.ReadCloser); ok {
+				return rc, nil
+			}
+			return ioutil.NopCloser(reader), nil
+		}
+	}
+
+	return nil
+}
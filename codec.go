@@ -0,0 +1,212 @@
+package httpc
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Encoder body 编码器
+type Encoder func(v interface{}) ([]byte, error)
+
+// Decoder 响应解码器
+type Decoder func(r io.Reader, v interface{}) error
+
+var (
+	codecMu  sync.RWMutex
+	encoders = map[string]Encoder{
+		MIMEApplicationJSON:    json.Marshal,
+		MIMEApplicationXML:     xml.Marshal,
+		MIMETextXML:            xml.Marshal,
+		MIMEXWWWFormURLEncoded: encodeForm,
+	}
+	decoders = map[string]Decoder{
+		MIMEApplicationJSON:    decodeJSON,
+		MIMEApplicationXML:     decodeXML,
+		MIMETextXML:            decodeXML,
+		MIMEXWWWFormURLEncoded: decodeForm,
+		MIMETextPlain:          decodeText,
+	}
+)
+
+// RegisterEncoder 注册 body 编码器（如 msgpack protobuf）SetBody 会按 Content-Type 选择编码器
+func RegisterEncoder(mediaType string, encoder Encoder) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+
+	encoders[strings.ToLower(mediaType)] = encoder
+}
+
+// lookupEncoder 按内容类型查找编码器 未注册的 +json +xml 类型使用对应的编码器
+func lookupEncoder(contentType string) (Encoder, bool) {
+	mediaType := parseMediaType(contentType)
+	if mediaType == "" {
+		return nil, false
+	}
+
+	codecMu.RLock()
+	encoder, ok := encoders[mediaType]
+	codecMu.RUnlock()
+	if ok {
+		return encoder, true
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return json.Marshal, true
+	case strings.HasSuffix(mediaType, "+xml"):
+		return xml.Marshal, true
+	}
+
+	return nil, false
+}
+
+// RegisterDecoder 注册响应解码器 Response.Decode 会按响应的 Content-Type 选择解码器
+func RegisterDecoder(mediaType string, decoder Decoder) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+
+	decoders[strings.ToLower(mediaType)] = decoder
+}
+
+// lookupDecoder 按内容类型查找解码器 未声明类型时使用 json
+func lookupDecoder(contentType string) (Decoder, error) {
+	if contentType == "" {
+		return decodeJSON, nil
+	}
+
+	mediaType := parseMediaType(contentType)
+
+	codecMu.RLock()
+	decoder, ok := decoders[mediaType]
+	codecMu.RUnlock()
+	if ok {
+		return decoder, nil
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return decodeJSON, nil
+	case strings.HasSuffix(mediaType, "+xml"):
+		return decodeXML, nil
+	}
+
+	return nil, fmt.Errorf("unsupported response content type: %q", contentType)
+}
+
+// decoderMediaTypes 所有已注册解码器的内容类型
+func decoderMediaTypes() []string {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+
+	var out []string
+	for k := range decoders {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+// parseMediaType 获取不带参数的小写内容类型
+func parseMediaType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	return mediaType
+}
+
+// encodeForm 简单表单编码器 struct 使用 form 标签（规则与 SetURLQueryStruct 相同）
+func encodeForm(v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case url.Values:
+		return []byte(value.Encode()), nil
+	case map[string][]string:
+		return []byte(url.Values(value).Encode()), nil
+	case map[string]string:
+		values := url.Values{}
+		for k, v := range value {
+			values.Set(k, v)
+		}
+		return []byte(values.Encode()), nil
+	}
+
+	if refValue := reflect.Indirect(reflect.ValueOf(v)); refValue.Kind() == reflect.Struct {
+		values := url.Values{}
+		if err := encodeQueryStruct(refValue, "form", "", values); err != nil {
+			return nil, err
+		}
+		return []byte(values.Encode()), nil
+	}
+
+	return nil, fmt.Errorf("can not encode %T as form", v)
+}
+
+func decodeJSON(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func decodeXML(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// decodeForm 简单表单解码器 接受 *url.Values *map[string][]string *map[string]string
+func decodeForm(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(b))
+	if err != nil {
+		return err
+	}
+
+	switch ptr := v.(type) {
+	case *url.Values:
+		*ptr = values
+	case *map[string][]string:
+		*ptr = values
+	case *map[string]string:
+		*ptr = map[string]string{}
+		for k := range values {
+			(*ptr)[k] = values.Get(k)
+		}
+	default:
+		return fmt.Errorf("can not decode form into %T", v)
+	}
+
+	return nil
+}
+
+// decodeText 文本解码器 接受 *string *[]byte
+func decodeText(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	switch ptr := v.(type) {
+	case *string:
+		*ptr = string(b)
+	case *[]byte:
+		*ptr = b
+	default:
+		return fmt.Errorf("can not decode text into %T", v)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package httpc
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// HeaderAcceptEncoding 可接受的内容编码
+	HeaderAcceptEncoding = "Accept-Encoding"
+	// HeaderContentEncoding 内容编码
+	HeaderContentEncoding = "Content-Encoding"
+)
+
+// acceptEncoding 自动解压时声明支持的编码
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// contentDecoders 内容解码器
+var contentDecoders = map[string]func(r io.Reader) (io.ReadCloser, error){
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"x-gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": newDeflateReader,
+	"br": func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(brotli.NewReader(r)), nil
+	},
+	"zstd": func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	},
+}
+
+// contentEncoders 内容编码器
+var contentEncoders = map[string]func(w io.Writer) (io.WriteCloser, error){
+	"gzip": func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	},
+	"deflate": func(w io.Writer) (io.WriteCloser, error) {
+		return zlib.NewWriter(w), nil
+	},
+	"br": func(w io.Writer) (io.WriteCloser, error) {
+		return brotli.NewWriter(w), nil
+	},
+	"zstd": func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	},
+}
+
+// SetBodyCompression 设置请求 body 的压缩方式（gzip deflate br zstd）发送时边读边压缩
+func (c Client) SetBodyCompression(encoding string) *Client {
+	encoding = strings.ToLower(encoding)
+	if _, ok := contentEncoders[encoding]; !ok {
+		return c.handleError(fmt.Errorf("unsupported body compression: %q", encoding))
+	}
+
+	c.BodyCompression = encoding
+	return &c
+}
+
+// DeleteBodyCompression 取消请求 body 压缩
+func (c Client) DeleteBodyCompression() *Client {
+	c.BodyCompression = ""
+	return &c
+}
+
+// compress 返回压缩后的 body（长度未知）
+func (b *requestBody) compress(encoding string) *requestBody {
+	if b.size == 0 {
+		return b
+	}
+
+	return &requestBody{size: -1, replayable: b.replayable, release: b.release, open: func() (io.Reader, error) {
+		reader, err := b.open()
+		if err != nil || reader == nil {
+			return reader, err
+		}
+
+		pr, pw := io.Pipe()
+		go func() {
+			zw, err := contentEncoders[encoding](pw)
+			if err == nil {
+				_, err = io.Copy(zw, reader)
+				if closeErr := zw.Close(); err == nil {
+					err = closeErr
+				}
+			}
+			if closer, ok := reader.(io.Closer); ok {
+				closer.Close()
+			}
+			pw.CloseWithError(err)
+		}()
+
+		return pr, nil
+	}}
+}
+
+// newDeflateReader deflate 按规范是 zlib 格式 但也有服务端直接返回原始 deflate 数据
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+
+	return flate.NewReader(br), nil
+}
+
+// shouldDecompress 是否由本库声明并解压（与 http.Transport 自动处理 gzip 的条件一致）
+func (c Client) shouldDecompress(req *http.Request) bool {
+	if tr, ok := c.Client.Transport.(*http.Transport); ok && tr.DisableCompression {
+		return false
+	}
+
+	return req.Header.Get(HeaderAcceptEncoding) == "" && req.Header.Get("Range") == "" && req.Method != http.MethodHead
+}
+
+// decompressBody 按 Content-Encoding 解压响应（读取时才进行解压 Bytes 等方法的大小限制作用于解压后的数据）
+func decompressBody(resp *http.Response) {
+	encoding := strings.TrimSpace(strings.ToLower(resp.Header.Get(HeaderContentEncoding)))
+	if encoding == "" || encoding == "identity" || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+
+	// 多重编码时按相反顺序解码
+	encodings := strings.Split(encoding, ",")
+	for _, v := range encodings {
+		if _, ok := contentDecoders[strings.TrimSpace(v)]; !ok && strings.TrimSpace(v) != "identity" {
+			return
+		}
+	}
+
+	resp.Body = &decompressReader{body: resp.Body, encodings: encodings}
+	resp.Header.Del(HeaderContentEncoding)
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// decompressReader 首次读取时才创建解码器
+type decompressReader struct {
+	body      io.ReadCloser
+	encodings []string
+
+	reader  io.Reader
+	closers []io.Closer
+	err     error
+}
+
+func (r *decompressReader) Read(p []byte) (int, error) {
+	if r.reader == nil && r.err == nil {
+		var reader io.Reader = r.body
+		for i := len(r.encodings) - 1; i >= 0; i-- {
+			encoding := strings.TrimSpace(r.encodings[i])
+			if encoding == "identity" {
+				continue
+			}
+
+			rc, err := contentDecoders[encoding](reader)
+			if err != nil {
+				r.err = err
+				break
+			}
+			r.closers = append(r.closers, rc)
+			reader = rc
+		}
+		r.reader = reader
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	return r.reader.Read(p)
+}
+
+func (r *decompressReader) Close() error {
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		r.closers[i].Close()
+	}
+
+	return r.body.Close()
+}
@@ -0,0 +1,122 @@
+package httpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDecompress(t *testing.T) {
+	data := strings.Repeat("niconiconi", 1024)
+	writers := map[string]func(w io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"br":      func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		"zstd": func(w io.Writer) io.WriteCloser {
+			zw, _ := zstd.NewWriter(w)
+			return zw
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderAcceptEncoding) != acceptEncoding {
+			t.Errorf("Accept-Encoding 错误: %q", r.Header.Get(HeaderAcceptEncoding))
+		}
+		encoding := strings.TrimPrefix(r.URL.Path, "/")
+		buf := &bytes.Buffer{}
+		zw := writers[encoding](buf)
+		zw.Write([]byte(data))
+		zw.Close()
+
+		w.Header().Set(HeaderContentEncoding, encoding)
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	for encoding := range writers {
+		res, err := Get(srv.URL + "/" + encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Text(len(data)) != data {
+			t.Errorf("%s 解压失败", encoding)
+		}
+
+		// 大小限制作用于解压后的数据
+		res, err = Get(srv.URL + "/" + encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := res.Bytes(1024); err == nil {
+			t.Errorf("%s 解压后的数据应受大小限制", encoding)
+		}
+	}
+}
+
+func TestSetBodyCompression(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, err := contentDecoders[r.Header.Get(HeaderContentEncoding)](r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		io.Copy(w, zr)
+	}))
+	defer srv.Close()
+
+	data := strings.Repeat("niconiconi", 1024)
+	for _, encoding := range []string{"gzip", "deflate", "br", "zstd"} {
+		res, err := SetBodyCompression(encoding).SetBody(ioutil.NopCloser(strings.NewReader(data))).Post(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Text(len(data)) != data {
+			t.Errorf("%s 压缩失败", encoding)
+		}
+	}
+
+	if SetBodyCompression("niconiconi").Error == nil {
+		t.Error("不支持的压缩方式应返回错误")
+	}
+}
+
+func TestSetBodyCompressionAbort(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// 请求在交给 Transport 之前失败 压缩协程也应退出
+	client := SetBodyCompression("gzip").OnRequest(func(req *http.Request) error {
+		return errors.New("abort")
+	})
+	for i := 0; i < 50; i++ {
+		if _, err := client.SetBody(strings.NewReader("niconiconi")).Post("http://127.0.0.1:1"); err == nil {
+			t.Fatal("应返回拦截器的错误")
+		}
+	}
+
+	if !waitGoroutines(before) {
+		t.Errorf("协程泄露: %d -> %d", before, runtime.NumGoroutine())
+	}
+}
+
+// waitGoroutines 等待协程数量回落到 n 以内
+func waitGoroutines(n int) bool {
+	for i := 0; i < 100; i++ {
+		if runtime.NumGoroutine() <= n {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
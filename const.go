@@ -3,6 +3,8 @@ package httpc
 const (
 	charsetUTF8 = "charset=UTF-8"
 
+	// HeaderAccept 可接受的内容类型
+	HeaderAccept = "Accept"
 	// HeaderContentType 内容类型
 	HeaderContentType = "Content-Type"
 	// HeaderContentDisposition 内容说明
@@ -21,5 +23,9 @@ const (
 	// MIMETextPlain 文本类型
 	MIMETextPlain = "text/plain"
 	// MIMEXWWWFormURLEncoded 简单表单
-	MIMEXWWWFormURLEncoded = "x-www-form-urlencoded"
+	MIMEXWWWFormURLEncoded = "application/x-www-form-urlencoded"
+	// MIMEApplicationMsgpack msgpack 类型
+	MIMEApplicationMsgpack = "application/msgpack"
+	// MIMEApplicationProtobuf protobuf 类型
+	MIMEApplicationProtobuf = "application/x-protobuf"
 )
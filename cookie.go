@@ -0,0 +1,171 @@
+package httpc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SetCookieJar 设置 cookie jar （响应中的 cookie 会被保存 后续请求自动携带）
+func (c Client) SetCookieJar(jar http.CookieJar) *Client {
+	c.Client.Jar = jar
+	return &c
+}
+
+// SetCookies 设置请求时携带的 cookie （不会保存到 cookie jar 中）
+func (c Client) SetCookies(cookies ...*http.Cookie) *Client {
+	c.Cookies = append(append([]*http.Cookie(nil), c.Cookies...), cookies...)
+	return &c
+}
+
+// DeleteCookies 删除通过 SetCookies 设置的 cookie
+func (c Client) DeleteCookies() *Client {
+	c.Cookies = nil
+	return &c
+}
+
+// PersistentJar 可以保存到文件的 cookie jar （json 格式）
+// 会话 cookie 同样会被保存 以便下次启动时不需要重新登录
+type PersistentJar struct {
+	mu      sync.Mutex
+	path    string
+	jar     *cookiejar.Jar
+	entries map[string]cookieEntry
+}
+
+// cookieEntry 保存的 cookie
+type cookieEntry struct {
+	URL    string       `json:"url"` // 设置 cookie 时的地址
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// NewPersistentJar 新建持久化 cookie jar 文件存在时会加载其中的 cookie
+func NewPersistentJar(path string) (*PersistentJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &PersistentJar{path: path, jar: jar, entries: map[string]cookieEntry{}}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []cookieEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+	for _, v := range entries {
+		u, err := url.Parse(v.URL)
+		if err != nil || v.Cookie == nil {
+			continue
+		}
+		j.SetCookies(u, []*http.Cookie{v.Cookie})
+	}
+
+	return j, nil
+}
+
+// SetCookies 实现 http.CookieJar
+func (j *PersistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	for _, v := range cookies {
+		cookie := *v
+		if cookie.MaxAge > 0 { // 统一转换为过期时间 以便保存
+			cookie.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+			cookie.MaxAge = 0
+		}
+
+		key := cookieKey(u, &cookie)
+		if cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(now)) {
+			delete(j.entries, key)
+			continue
+		}
+		j.entries[key] = cookieEntry{URL: (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String(), Cookie: &cookie}
+	}
+}
+
+// cookieKey 与 cookiejar 相同的规则生成 cookie 的唯一标识 （同一个 cookie 的更新会覆盖之前的记录）
+func cookieKey(u *url.URL, cookie *http.Cookie) string {
+	domain := strings.ToLower(strings.TrimPrefix(cookie.Domain, "."))
+	if domain == "" { // 仅限当前主机
+		domain = strings.ToLower(u.Hostname())
+	}
+
+	path := cookie.Path
+	if path == "" || path[0] != '/' {
+		path = cookieDefaultPath(u.Path)
+	}
+
+	return domain + ";" + path + ";" + cookie.Name
+}
+
+// cookieDefaultPath 未设置 Path 时的默认路径 （RFC 6265 5.1.4）
+func cookieDefaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// Cookies 实现 http.CookieJar
+func (j *PersistentJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.jar.Cookies(u)
+}
+
+// Save 保存到文件 （先写入临时文件再重命名）
+func (j *PersistentJar) Save() error {
+	j.mu.Lock()
+	now := time.Now()
+	entries := make([]cookieEntry, 0, len(j.entries))
+	for k, v := range j.entries {
+		if !v.Cookie.Expires.IsZero() && v.Cookie.Expires.Before(now) {
+			delete(j.entries, k)
+			continue
+		}
+		entries = append(entries, v)
+	}
+	j.mu.Unlock()
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return commitFile(tmp, j.path, 0600) // cookie 中通常有登录凭证
+}
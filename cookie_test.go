@@ -0,0 +1,90 @@
+package httpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistentJar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "token", Value: "niconiconi", Path: "/", MaxAge: 3600})
+			return
+		}
+		if ck, err := r.Cookie("token"); err == nil {
+			w.Write([]byte(ck.Value))
+		}
+		if ck, err := r.Cookie("name"); err == nil {
+			w.Write([]byte(ck.Value))
+		}
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "httpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cookies.json")
+
+	jar, err := NewPersistentJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := SetCookieJar(jar).Get(srv.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if err := jar.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载后依然处于登录状态
+	jar, err = NewPersistentJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = SetCookieJar(jar).SetCookies(&http.Cookie{Name: "name", Value: "elissa"}).Get(srv.URL + "/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Text(); text != "niconiconielissa" {
+		t.Errorf("cookie 未携带: %q", text)
+	}
+}
+
+func TestPersistentJarKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	jar, err := NewPersistentJar(filepath.Join(dir, "cookies.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未设置 Path 时使用请求地址的目录 未设置 Domain 时仅限当前主机
+	u, _ := url.Parse("https://Example.com/api/login")
+	jar.SetCookies(u, []*http.Cookie{{Name: "token", Value: "1"}})
+	u, _ = url.Parse("https://example.com/api/refresh")
+	jar.SetCookies(u, []*http.Cookie{{Name: "token", Value: "2", Path: "/api"}})
+	jar.SetCookies(u, []*http.Cookie{{Name: "name", Value: "niconiconi", Domain: ".Example.com"}, {Name: "name", Value: "elissa", Domain: "example.com"}})
+
+	if len(jar.entries) != 2 {
+		t.Errorf("同一个 cookie 应只保存一条记录: %v", jar.entries)
+	}
+	if v, ok := jar.entries["example.com;/api;token"]; !ok || v.Cookie.Value != "2" {
+		t.Error("cookie 未被更新", jar.entries)
+	}
+	if v, ok := jar.entries["example.com;/api;name"]; !ok || v.Cookie.Value != "elissa" {
+		t.Error("cookie 未被更新", jar.entries)
+	}
+}
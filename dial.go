@@ -0,0 +1,196 @@
+package httpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+)
+
+// AddressFamily 连接使用的地址族
+type AddressFamily int
+
+const (
+	DualStack AddressFamily = iota // IPv4 与 IPv6 同时尝试（默认）
+	IPv4Only                       // 只使用 IPv4
+	IPv6Only                       // 只使用 IPv6
+)
+
+// happyEyeballsDelay 上一个连接未完成时 开始尝试下一个地址前等待的时间（RFC 8305 推荐值）
+const happyEyeballsDelay = 250 * time.Millisecond
+
+// SetAddressFamily 设置连接使用的地址族（如 IPv6 路由不通时只使用 IPv4）
+// 会创建新的 Transport（独立的连接池）
+func (c Client) SetAddressFamily(family AddressFamily) *Client {
+	c.AddressFamily = family
+	return c.applyDialer()
+}
+
+// applyDialer 按解析器、地址族以及域名地址覆盖重新设置 DialContext
+func (c Client) applyDialer() *Client {
+	tr := c.transport()
+	tr.DialContext = newDialContext(c.Resolver, c.AddressFamily, c.HostOverrides)
+	c.Client.Transport = tr
+
+	return &c
+}
+
+// newDialContext 生成 DialContext 优先使用 overrides 中的地址
+func newDialContext(r *Resolver, family AddressFamily, overrides map[string]string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		network = familyNetwork(network, family)
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips := lookupOverride(overrides, host, port)
+		if ips == nil {
+			if r == nil { // 使用系统解析 标准库本身就支持 Happy Eyeballs
+				return dialer.DialContext(ctx, network, addr)
+			}
+			if ips, err = r.LookupIP(ctx, host); err != nil {
+				return nil, err
+			}
+		}
+		ips = sortIPs(filterIPs(network, ips))
+		if len(ips) == 0 {
+			return nil, &net.AddrError{Err: "no suitable address found", Addr: host}
+		}
+
+		addrs := make([]string, len(ips))
+		for i, ip := range ips {
+			addrs[i] = net.JoinHostPort(ip.String(), port)
+		}
+
+		return dialParallel(ctx, dialer, network, addrs, happyEyeballsDelay)
+	}
+}
+
+// familyNetwork 按地址族限定网络类型
+func familyNetwork(network string, family AddressFamily) string {
+	if strings.HasSuffix(network, "4") || strings.HasSuffix(network, "6") {
+		return network
+	}
+
+	switch family {
+	case IPv4Only:
+		return network + "4"
+	case IPv6Only:
+		return network + "6"
+	}
+
+	return network
+}
+
+// filterIPs 按网络类型过滤地址
+func filterIPs(network string, ips []net.IP) []net.IP {
+	var result []net.IP
+	for _, v := range ips {
+		isV4 := v.To4() != nil
+		switch {
+		case strings.HasSuffix(network, "4") && !isV4, strings.HasSuffix(network, "6") && isV4:
+			continue
+		}
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// sortIPs 按 RFC 8305 交替排列两种地址族 IPv6 优先
+func sortIPs(ips []net.IP) []net.IP {
+	var primary, fallback []net.IP
+	for _, v := range ips {
+		if v.To4() == nil {
+			primary = append(primary, v)
+		} else {
+			fallback = append(fallback, v)
+		}
+	}
+
+	result := make([]net.IP, 0, len(ips))
+	for i := 0; i < len(primary) || i < len(fallback); i++ {
+		if i < len(primary) {
+			result = append(result, primary[i])
+		}
+		if i < len(fallback) {
+			result = append(result, fallback[i])
+		}
+	}
+
+	return result
+}
+
+// dialResult 单个地址的连接结果
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// dialParallel 依次开始连接每个地址 上一个连接失败或等待 delay 后开始下一个 使用最先成功的连接
+func dialParallel(ctx context.Context, dialer *net.Dialer, network string, addrs []string, delay time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, len(addrs))
+	next, pending := 0, 0
+	start := func() {
+		go func(addr string) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			results <- dialResult{conn: conn, err: err}
+		}(addrs[next])
+		next++
+		pending++
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	resetTimer := func() {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(delay)
+	}
+
+	start()
+	var firstErr error
+	for pending > 0 {
+		select {
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				go closeLosers(results, pending)
+				return res.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			if next < len(addrs) {
+				start()
+				resetTimer()
+			}
+		case <-timer.C:
+			if next < len(addrs) {
+				start()
+				timer.Reset(delay)
+			}
+		}
+	}
+
+	return nil, firstErr
+}
+
+// closeLosers 关闭其它同时建立成功的连接
+func closeLosers(results <-chan dialResult, pending int) {
+	for i := 0; i < pending; i++ {
+		if res := <-results; res.conn != nil {
+			res.conn.Close()
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package httpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortIPs(t *testing.T) {
+	var ips []net.IP
+	for _, v := range []string{"::1", "::2", "::3", "127.0.0.1", "127.0.0.2"} {
+		ips = append(ips, net.ParseIP(v))
+	}
+
+	var got []string
+	for _, v := range sortIPs(ips) {
+		got = append(got, v.String())
+	}
+	if want := []string{"::1", "127.0.0.1", "::2", "127.0.0.2", "::3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("排序结果为 %v 期望 %v", got, want)
+	}
+
+	// 即使 IPv4 地址在前 也应优先尝试 IPv6
+	got = nil
+	for _, v := range sortIPs([]net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("127.0.0.2"), net.ParseIP("::1")}) {
+		got = append(got, v.String())
+	}
+	if want := []string{"::1", "127.0.0.1", "127.0.0.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("排序结果为 %v 期望 %v", got, want)
+	}
+
+	if got := filterIPs("tcp4", ips); len(got) != 2 {
+		t.Error("tcp4 应只保留 IPv4 地址", got)
+	}
+	if familyNetwork("tcp", IPv6Only) != "tcp6" || familyNetwork("tcp4", IPv6Only) != "tcp4" || familyNetwork("tcp", DualStack) != "tcp" {
+		t.Error("网络类型不一致")
+	}
+}
+
+func TestDialParallel(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// 192.0.2.1 为文档保留地址 连接不会成功 应在等待后尝试下一个地址
+	start := time.Now()
+	conn, err := dialParallel(context.Background(), &net.Dialer{Timeout: 10 * time.Second}, "tcp", []string{"192.0.2.1:80", ln.Addr().String()}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if time.Since(start) > 5*time.Second {
+		t.Error("不应等待第一个地址超时")
+	}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	if _, err := dialParallel(context.Background(), &net.Dialer{}, "tcp", []string{closed.Addr().String(), closed.Addr().String()}, time.Second); err == nil {
+		t.Error("所有地址都失败时应返回错误")
+	}
+}
+
+func TestSetAddressFamily(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("niconiconi"))
+	}))
+	defer srv.Close()
+
+	// 服务只监听在 IPv4 上
+	addr, _ := newDNSStub(t, map[string][]string{"example.test": {"::1", "127.0.0.1"}}, 60)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	url := "http://example.test:" + port
+
+	for family, ok := range map[AddressFamily]bool{DualStack: true, IPv4Only: true, IPv6Only: false} {
+		res, err := UseDNS(addr).SetAddressFamily(family).Get(url)
+		if ok && (err != nil || res.Text() != "niconiconi") {
+			t.Error(family, "连接失败", err)
+		}
+		if !ok && err == nil {
+			t.Error(family, "不应连接成功")
+		}
+	}
+
+	if _, err := SetAddressFamily(IPv6Only).Get(srv.URL); err == nil {
+		t.Error("未使用自定义解析时也应限制地址族")
+	}
+}
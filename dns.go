@@ -0,0 +1,496 @@
+package httpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	stdURL "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Resolver 域名解析器 按记录的 TTL 缓存解析结果，多个 dns 服务器时依次尝试
+// 每个 Resolver 拥有独立的缓存，可以在多个 Client 之间共享
+type Resolver struct {
+	Timeout    time.Duration // 单个 dns 服务器的超时时间 默认 5s
+	DefaultTTL time.Duration // 使用系统解析时的缓存时间（系统解析无法获取 TTL）默认 30s
+	MaxTTL     time.Duration // 缓存时间上限 0 为不限制
+	TLSConfig  *tls.Config   // DoH 以及 DoT 使用的 TLS 配置
+	Hosts      *Hosts        // 优先使用的 hosts 文件 默认为 DefaultHosts
+
+	servers []dnsServer
+	now     func() time.Time // 测试时替换
+
+	mu    sync.Mutex
+	cache map[string]dnsCacheEntry
+}
+
+// dnsCacheEntry 缓存的解析结果
+type dnsCacheEntry struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+// dnsServer dns 服务器 发送查询报文并返回响应报文
+type dnsServer interface {
+	exchange(ctx context.Context, query []byte) ([]byte, error)
+	String() string
+}
+
+// NewResolver 新建解析器 servers 为 dns 服务器地址 为空时使用系统解析
+// 支持 host、host:port（默认端口为 53）、https://host/dns-query（DNS-over-HTTPS）以及 tls://host:853（DNS-over-TLS）
+func NewResolver(servers ...string) (*Resolver, error) {
+	r := &Resolver{}
+	for k, v := range servers {
+		if v == "" {
+			continue
+		}
+
+		server, err := parseDNSServer(r, v)
+		if err != nil {
+			return nil, fmt.Errorf("dns %d: %w", k, err)
+		}
+		r.servers = append(r.servers, server)
+	}
+
+	return r, nil
+}
+
+// parseDNSServer 解析 dns 服务器地址
+// https://dns.example/dns-query 为 DNS-over-HTTPS，tls://host:853 为 DNS-over-TLS，其它为普通 dns
+func parseDNSServer(r *Resolver, server string) (dnsServer, error) {
+	if strings.Contains(server, "://") {
+		u, err := stdURL.Parse(server)
+		if err != nil {
+			return nil, err
+		}
+		if u.Host == "" {
+			return nil, errors.New("dns host is empty")
+		}
+
+		switch u.Scheme {
+		case "https":
+			return &dohDNSServer{resolver: r, url: u.String()}, nil
+		case "tls":
+			addr := u.Host
+			if u.Port() == "" {
+				addr = net.JoinHostPort(u.Hostname(), "853")
+			}
+			return &dotDNSServer{resolver: r, addr: addr, serverName: u.Hostname()}, nil
+		}
+		return nil, fmt.Errorf("unsupported dns scheme %q", u.Scheme)
+	}
+
+	if ip := net.ParseIP(server); ip != nil { // 不带端口的 IPv6 地址无法使用 SplitHostPort 解析
+		return &udpDNSServer{addr: net.JoinHostPort(server, "53")}, nil
+	}
+
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		// 端口不存在自动加一个
+		addrErr, ok := err.(*net.AddrError)
+		if !ok || addrErr.Err != "missing port in address" {
+			return nil, err
+		}
+		host, port = strings.Trim(server, "[]"), "53"
+	}
+	if host == "" {
+		return nil, errors.New("dns host is empty")
+	}
+
+	return &udpDNSServer{addr: net.JoinHostPort(host, port)}, nil
+}
+
+// UseDNS 使用指定 dns 解析域名（带缓存 多个 dns 时依次尝试）
+// 与 SetProxy 相同会创建新的 Transport 以及解析器 请保存返回的客户端复用
+func (c Client) UseDNS(dns ...string) *Client {
+	r, err := NewResolver(dns...)
+	if err != nil {
+		return c.handleError(err)
+	}
+
+	return c.UseResolver(r)
+}
+
+// UseResolver 使用指定解析器解析域名（连接时会以 Happy Eyeballs 的方式尝试所有解析到的地址）
+// 会创建新的 Transport（独立的连接池）
+func (c Client) UseResolver(r *Resolver) *Client {
+	c.Resolver = r
+	return c.applyDialer()
+}
+
+// LookupIP 解析域名 返回所有地址
+func (r *Resolver) LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	hosts := r.Hosts
+	if hosts == nil {
+		hosts = DefaultHosts
+	}
+	if ips := hosts.Lookup(host); len(ips) != 0 {
+		return ips, nil
+	}
+
+	key := normalizeHost(host)
+	now := r.clock()
+	r.mu.Lock()
+	entry, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.ips, nil
+	}
+
+	ips, ttl, err := r.lookup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if r.MaxTTL > 0 && ttl > r.MaxTTL {
+		ttl = r.MaxTTL
+	}
+
+	if ttl > 0 {
+		r.mu.Lock()
+		if r.cache == nil {
+			r.cache = map[string]dnsCacheEntry{}
+		}
+		r.cache[key] = dnsCacheEntry{ips: ips, expires: now.Add(ttl)}
+		r.mu.Unlock()
+	}
+
+	return ips, nil
+}
+
+// clock 当前时间
+func (r *Resolver) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// lookup 实际进行解析 返回地址以及缓存时间
+func (r *Resolver) lookup(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	if len(r.servers) == 0 {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		ips := make([]net.IP, 0, len(addrs))
+		for _, v := range addrs {
+			ips = append(ips, v.IP)
+		}
+		ttl := r.DefaultTTL
+		if ttl == 0 {
+			ttl = 30 * time.Second
+		}
+		return ips, ttl, nil
+	}
+
+	var dnsErr error
+	for _, server := range r.servers {
+		ips, ttl, err := r.lookupServer(ctx, server, host)
+		if err == nil {
+			return ips, ttl, nil
+		}
+
+		var e *net.DNSError
+		if errors.As(err, &e) && e.IsNotFound { // 域名不存在时其它服务器的结果也是一样的
+			return nil, 0, err
+		}
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+
+		if dnsErr == nil {
+			dnsErr = fmt.Errorf("%s: %w", server, err)
+		} else {
+			dnsErr = fmt.Errorf("%w -> %s: %s", dnsErr, server, err)
+		}
+	}
+
+	return nil, 0, dnsErr
+}
+
+// lookupServer 使用指定服务器同时查询 AAAA 以及 A 记录（IPv6 地址在前）
+func (r *Resolver) lookupServer(ctx context.Context, server dnsServer, host string) ([]net.IP, time.Duration, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		ips []net.IP
+		ttl time.Duration
+		err error
+	}
+	types := []dnsmessage.Type{dnsmessage.TypeAAAA, dnsmessage.TypeA}
+	results := make([]result, len(types))
+
+	var wg sync.WaitGroup
+	for i, qtype := range types {
+		wg.Add(1)
+		go func(i int, qtype dnsmessage.Type) {
+			defer wg.Done()
+			res := &results[i]
+			res.ips, res.ttl, res.err = queryDNS(ctx, server, host, qtype)
+		}(i, qtype)
+	}
+	wg.Wait()
+
+	var ips []net.IP
+	var ttl time.Duration = -1
+	for _, v := range results {
+		if v.err != nil {
+			continue
+		}
+		ips = append(ips, v.ips...)
+		if len(v.ips) != 0 && (ttl < 0 || v.ttl < ttl) {
+			ttl = v.ttl
+		}
+	}
+	if len(ips) != 0 {
+		return ips, ttl, nil
+	}
+
+	for _, v := range results {
+		if v.err != nil {
+			return nil, 0, v.err
+		}
+	}
+	return nil, 0, &net.DNSError{Err: "no such host", Name: host, Server: server.String(), IsNotFound: true}
+}
+
+// queryDNS 查询一种类型的记录
+func queryDNS(ctx context.Context, server dnsServer, host string, qtype dnsmessage.Type) ([]net.IP, time.Duration, error) {
+	name, err := dnsmessage.NewName(host + ".")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	id := uint16(rand.Uint32())
+	query, err := (&dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	b, err := server.exchange(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(b); err != nil {
+		return nil, 0, err
+	}
+	if msg.ID != id || !msg.Response || len(msg.Questions) != 1 || !strings.EqualFold(msg.Questions[0].Name.String(), name.String()) {
+		return nil, 0, errors.New("dns response does not match query")
+	}
+
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, Server: server.String(), IsNotFound: true}
+	default:
+		return nil, 0, &net.DNSError{Err: "server misbehaving: " + msg.RCode.String(), Name: host, Server: server.String(), IsTemporary: true}
+	}
+
+	var ips []net.IP
+	var ttl uint32
+	for _, v := range msg.Answers {
+		var ip net.IP
+		switch body := v.Body.(type) {
+		case *dnsmessage.AResource:
+			ip = net.IP(body.A[:])
+		case *dnsmessage.AAAAResource:
+			ip = net.IP(body.AAAA[:])
+		default:
+			continue
+		}
+		if len(ips) == 0 || v.Header.TTL < ttl {
+			ttl = v.Header.TTL
+		}
+		ips = append(ips, ip)
+	}
+
+	return ips, time.Duration(ttl) * time.Second, nil
+}
+
+// udpDNSServer 普通 dns 服务器 响应被截断时使用 tcp 重新查询
+type udpDNSServer struct {
+	addr string
+}
+
+func (s *udpDNSServer) String() string {
+	return s.addr
+}
+
+func (s *udpDNSServer) exchange(ctx context.Context, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	b := make([]byte, 512)
+	n, err := conn.Read(b)
+	if err != nil {
+		return nil, err
+	}
+	if n > 2 && b[2]&0x02 != 0 { // TC 标志
+		return s.exchangeTCP(ctx, query)
+	}
+
+	return b[:n], nil
+}
+
+// exchangeTCP 使用 tcp 查询
+func (s *udpDNSServer) exchangeTCP(ctx context.Context, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	return exchangeStream(conn, query)
+}
+
+// exchangeStream 在流式连接上发送查询（报文前带两个字节的长度）
+func exchangeStream(conn io.ReadWriter, query []byte) ([]byte, error) {
+	b := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(b, uint16(len(query)))
+	copy(b[2:], query)
+	if _, err := conn.Write(b); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(conn, b[:2]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(b[:2]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// dohDNSServer DNS-over-HTTPS 服务器（RFC 8484）
+type dohDNSServer struct {
+	resolver *Resolver
+	url      string
+
+	once   sync.Once
+	client *http.Client
+}
+
+func (s *dohDNSServer) String() string {
+	return s.url
+}
+
+func (s *dohDNSServer) exchange(ctx context.Context, query []byte) ([]byte, error) {
+	s.once.Do(func() {
+		if s.client == nil {
+			s.client = &http.Client{Transport: &http.Transport{TLSClientConfig: s.resolver.TLSConfig.Clone(), ForceAttemptHTTP2: true}}
+		}
+	})
+
+	// ID 使用 0 以便于缓存（RFC 8484 4.1）
+	id := append([]byte(nil), query[:2]...)
+	query = append([]byte{0, 0}, query[2:]...)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(HeaderContentType, mimeDNSMessage)
+	req.Header.Set(HeaderAccept, mimeDNSMessage)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dns-over-https: unexpected status %s", resp.Status)
+	}
+	if parseMediaType(resp.Header.Get(HeaderContentType)) != mimeDNSMessage {
+		return nil, fmt.Errorf("dns-over-https: unexpected content type %q", resp.Header.Get(HeaderContentType))
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, 65535))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 2 {
+		return nil, errors.New("dns-over-https: response too short")
+	}
+	copy(b, id)
+
+	return b, nil
+}
+
+// mimeDNSMessage DoH 使用的内容类型
+const mimeDNSMessage = "application/dns-message"
+
+// dotDNSServer DNS-over-TLS 服务器（RFC 7858）
+type dotDNSServer struct {
+	resolver   *Resolver
+	addr       string
+	serverName string
+}
+
+func (s *dotDNSServer) String() string {
+	return "tls://" + s.addr
+}
+
+func (s *dotDNSServer) exchange(ctx context.Context, query []byte) ([]byte, error) {
+	config := s.resolver.TLSConfig.Clone()
+	if config == nil {
+		config = &tls.Config{}
+	}
+	if config.ServerName == "" {
+		config.ServerName = s.serverName
+	}
+
+	d := tls.Dialer{Config: config}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	return exchangeStream(conn, query)
+}
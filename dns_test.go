@@ -0,0 +1,253 @@
+package httpc
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsStubAnswer 按 records（域名 -> 地址）生成响应报文
+func dnsStubAnswer(query []byte, records map[string][]string, ttl uint32) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || len(msg.Questions) != 1 {
+		return nil
+	}
+	q := msg.Questions[0]
+	msg.Response = true
+
+	addrs, ok := records[strings.TrimSuffix(q.Name.String(), ".")]
+	if !ok {
+		msg.RCode = dnsmessage.RCodeNameError
+	}
+	for _, v := range addrs {
+		ip := net.ParseIP(v)
+		header := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: ttl}
+		if ip4 := ip.To4(); ip4 != nil && q.Type == dnsmessage.TypeA {
+			header.Type = dnsmessage.TypeA
+			r := &dnsmessage.AResource{}
+			copy(r.A[:], ip4)
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: header, Body: r})
+		} else if ip4 == nil && q.Type == dnsmessage.TypeAAAA {
+			header.Type = dnsmessage.TypeAAAA
+			r := &dnsmessage.AAAAResource{}
+			copy(r.AAAA[:], ip)
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: header, Body: r})
+		}
+	}
+
+	b, _ := msg.Pack()
+	return b
+}
+
+// newDNSStub 启动本地 udp dns 服务器 返回地址以及收到的查询次数
+func newDNSStub(t *testing.T, records map[string][]string, ttl uint32) (string, *int32) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var count int32
+	go func() {
+		b := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(b)
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&count, 1)
+			conn.WriteTo(dnsStubAnswer(b[:n], records, ttl), addr)
+		}
+	}()
+
+	return conn.LocalAddr().String(), &count
+}
+
+func TestResolverCache(t *testing.T) {
+	addr, count := newDNSStub(t, map[string][]string{"example.test": {"127.0.0.1", "::1"}}, 60)
+
+	r, err := NewResolver("", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	ips, err := r.LookupIP(context.Background(), "example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 2 || !ips[0].Equal(net.ParseIP("::1")) || !ips[1].Equal(net.ParseIP("127.0.0.1")) {
+		t.Error("解析结果不一致", ips)
+	}
+	if _, err := r.LookupIP(context.Background(), "EXAMPLE.test."); err != nil || atomic.LoadInt32(count) != 2 {
+		t.Error("TTL 内应使用缓存", atomic.LoadInt32(count))
+	}
+
+	now = now.Add(61 * time.Second)
+	if _, err := r.LookupIP(context.Background(), "example.test"); err != nil || atomic.LoadInt32(count) != 4 {
+		t.Error("TTL 过期后应重新查询", atomic.LoadInt32(count))
+	}
+
+	if _, err := r.LookupIP(context.Background(), "none.test"); err == nil {
+		t.Error("域名不存在时应返回错误")
+	}
+
+	if _, err := NewResolver("[::1"); err == nil {
+		t.Error("错误的 dns 地址应返回错误")
+	}
+}
+
+func TestParseDNSServer(t *testing.T) {
+	cases := map[string]string{
+		"8.8.8.8":                   "8.8.8.8:53",
+		"8.8.8.8:5353":              "8.8.8.8:5353",
+		"2001:4860:4860::8888":      "[2001:4860:4860::8888]:53",
+		"[2001:4860:4860::8888]":    "[2001:4860:4860::8888]:53",
+		"[2001:4860:4860::8888]:54": "[2001:4860:4860::8888]:54",
+	}
+	for server, want := range cases {
+		got, err := parseDNSServer(nil, server)
+		if err != nil {
+			t.Error(server, err)
+			continue
+		}
+		if udp, ok := got.(*udpDNSServer); !ok || udp.addr != want {
+			t.Errorf("%s 解析为 %v 期望 %s", server, got, want)
+		}
+	}
+}
+
+func TestResolverFailover(t *testing.T) {
+	addr, _ := newDNSStub(t, map[string][]string{"example.test": {"127.0.0.1"}}, 60)
+
+	// 关闭的端口会立即返回错误
+	closed, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	r, err := NewResolver(closed.LocalAddr().String(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Timeout = time.Second
+
+	if ips, err := r.LookupIP(context.Background(), "example.test"); err != nil || len(ips) != 1 {
+		t.Error("应尝试下一个 dns 服务器", ips, err)
+	}
+}
+
+func TestUseDNS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("niconiconi"))
+	}))
+	defer srv.Close()
+
+	// 127.0.0.2 上没有监听 应继续尝试下一个地址
+	addr, _ := newDNSStub(t, map[string][]string{"example.test": {"127.0.0.2", "127.0.0.1"}}, 60)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	res, err := UseDNS(addr).Get("http://example.test:" + port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text() != "niconiconi" {
+		t.Error("响应不一致")
+	}
+
+	if c := UseDNS("[::1"); c.Error == nil {
+		t.Error("错误的 dns 地址应返回错误")
+	}
+}
+
+func TestResolverDoHAndDoT(t *testing.T) {
+	records := map[string][]string{"example.test": {"127.0.0.1"}}
+
+	doh := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get(HeaderContentType) != mimeDNSMessage {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		if len(b) < 2 || b[0] != 0 || b[1] != 0 {
+			t.Error("DoH 查询的 ID 应为 0")
+		}
+		w.Header().Set(HeaderContentType, mimeDNSMessage)
+		w.Write(dnsStubAnswer(b, records, 60))
+	}))
+	doh.Config.ErrorLog = log.New(ioutil.Discard, "", 0) // 证书校验失败的用例会产生握手错误日志
+	doh.StartTLS()
+	defer doh.Close()
+
+	// DoT 与 DoH 使用同一个证书
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", doh.TLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					var size [2]byte
+					if _, err := io.ReadFull(conn, size[:]); err != nil {
+						return
+					}
+					query := make([]byte, binary.BigEndian.Uint16(size[:]))
+					if _, err := io.ReadFull(conn, query); err != nil {
+						return
+					}
+					resp := dnsStubAnswer(query, records, 60)
+					binary.BigEndian.PutUint16(size[:], uint16(len(resp)))
+					conn.Write(append(size[:], resp...))
+				}
+			}()
+		}
+	}()
+
+	rootCAs := doh.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	for _, server := range []string{doh.URL + "/dns-query", "tls://" + ln.Addr().String()} {
+		r, err := NewResolver(server)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.TLSConfig = &tls.Config{RootCAs: rootCAs}
+
+		ips, err := r.LookupIP(context.Background(), "example.test")
+		if err != nil || len(ips) != 1 || !ips[0].Equal(net.ParseIP("127.0.0.1")) {
+			t.Error(server, "解析失败", ips, err)
+		}
+		if _, err := r.LookupIP(context.Background(), "none.test"); err == nil {
+			t.Error(server, "域名不存在时应返回错误")
+		}
+
+		// 未信任证书时应失败
+		r, _ = NewResolver(server)
+		if _, err := r.LookupIP(context.Background(), "example.test"); err == nil {
+			t.Error(server, "证书校验未生效")
+		}
+	}
+
+	if _, err := NewResolver("ftp://127.0.0.1"); err == nil {
+		t.Error("不支持的协议应返回错误")
+	}
+}
@@ -0,0 +1,168 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WriteTo 将响应内容写入 w （不限制大小 读取完后关闭 body）
+func (r *Response) WriteTo(w io.Writer) (int64, error) {
+	defer r.Body.Close()
+
+	return io.Copy(w, r.Body)
+}
+
+// SaveToFile 将响应内容保存到文件 （先写入临时文件，完成后再重命名，不会留下不完整的文件）
+func (r *Response) SaveToFile(path string) error {
+	if !r.IsSuccessful() {
+		return statusError(r)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		r.Body.Close()
+		return err
+	}
+
+	if _, err := r.WriteTo(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return commitFile(tmp, path, 0644)
+}
+
+// commitFile 落盘并重命名到目标路径
+func commitFile(f *os.File, path string, perm os.FileMode) error {
+	err := f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
+
+// Download 下载文件到指定路径 支持断点续传
+// 下载中的数据保存在 path + ".part" 中，再次下载时使用 Range 请求继续下载，并通过 If-Range 校验远端文件是否发生变化
+func (c Client) Download(ctx context.Context, url string, path string) error {
+	return c.download(ctx, url, path, true)
+}
+
+func (c Client) download(ctx context.Context, url string, path string, resume bool) error {
+	partPath := path + ".part"
+	validatorPath := partPath + ".validator"
+
+	var offset int64
+	var validator string
+	if resume {
+		if info, err := os.Stat(partPath); err == nil && info.Mode().IsRegular() {
+			if b, err := ioutil.ReadFile(validatorPath); err == nil && len(b) != 0 {
+				offset, validator = info.Size(), string(b)
+			}
+		}
+	}
+
+	// 状态码以及响应 body 由下载自行处理 不经过 ErrorOnStatus 以及 SetResult
+	// 断点续传的偏移量是相对于原始内容的 所以不能使用压缩
+	resp, err := c.DeleteErrorOnStatus().SetResult(nil).SetErrorResult(nil).OnRequest(func(req *http.Request) error {
+		req.Header.Set(HeaderAcceptEncoding, "identity")
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			req.Header.Set("If-Range", validator)
+		}
+		return nil
+	}).GetWithContext(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flag := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start, ok := parseContentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			return fmt.Errorf("unexpected content range: %q", resp.Header.Get("Content-Range"))
+		}
+		flag |= os.O_APPEND
+	case http.StatusOK: // 远端不支持 Range 或文件已经变化 需要重新下载
+		flag |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 && resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset) { // 已经下载完成
+			f, err := os.OpenFile(partPath, os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			if err := commitFile(f, path, 0644); err != nil {
+				return err
+			}
+			return os.Remove(validatorPath)
+		}
+		if resume {
+			discardBody(resp.Body)
+			return c.download(ctx, url, path, false)
+		}
+		fallthrough
+	default:
+		return statusError(resp)
+	}
+
+	// 只有强校验器可以用于 If-Range
+	validator = resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if validator != "" {
+		if err := ioutil.WriteFile(validatorPath, []byte(validator), 0644); err != nil {
+			return err
+		}
+	} else {
+		os.Remove(validatorPath)
+	}
+
+	f, err := os.OpenFile(partPath, flag, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := commitFile(f, path, 0644); err != nil {
+		return err
+	}
+	if err := os.Remove(validatorPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// parseContentRangeStart 解析 Content-Range 的起始位置 如 bytes 100-199/200
+func parseContentRangeStart(value string) (int64, bool) {
+	value = strings.TrimPrefix(value, "bytes ")
+	i := strings.IndexByte(value, '-')
+	if i < 0 {
+		return 0, false
+	}
+
+	start, err := strconv.ParseInt(value[:i], 10, 64)
+	return start, err == nil
+}
@@ -0,0 +1,99 @@
+package httpc
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownload(t *testing.T) {
+	data := strings.Repeat("niconiconi", 1024)
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"nico"`)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(data))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "httpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nico.txt")
+
+	// 模拟上次下载了一半
+	if err := ioutil.WriteFile(path+".part", []byte(data[:100]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path+".part.validator", []byte(`"nico"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Download(context.Background(), srv.URL, path); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadFile(path)
+	if string(b) != data {
+		t.Error("续传后的文件内容不一致")
+	}
+	if ranges[0] != "bytes=100-" {
+		t.Errorf("未使用 Range 请求: %q", ranges[0])
+	}
+
+	// 远端文件变化时应重新下载
+	ioutil.WriteFile(path+".part", []byte("foobar"), 0644)
+	ioutil.WriteFile(path+".part.validator", []byte(`"elissa"`), 0644)
+	if err := Download(context.Background(), srv.URL, path); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = ioutil.ReadFile(path)
+	if string(b) != data {
+		t.Error("重新下载后的文件内容不一致")
+	}
+
+	// 已经下载完成（416）时 ErrorOnStatus 以及 SetResult 不应影响下载
+	os.Remove(path)
+	ioutil.WriteFile(path+".part", []byte(data), 0644)
+	ioutil.WriteFile(path+".part.validator", []byte(`"nico"`), 0644)
+	var result map[string]interface{}
+	if err := ErrorOnStatus().SetResult(&result).SetErrorResult(&result).Download(context.Background(), srv.URL, path); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = ioutil.ReadFile(path)
+	if string(b) != data {
+		t.Error("已下载完成的文件未提交")
+	}
+}
+
+func TestSaveToFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("niconiconi"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "httpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nico.txt")
+
+	res, err := Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != "niconiconi" {
+		t.Error("保存的文件内容不一致")
+	}
+}
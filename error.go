@@ -0,0 +1,83 @@
+package httpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// httpErrorBodySize HTTPError 中最多保留的 body 长度
+const httpErrorBodySize = 4 * 1024
+
+// HTTPError 非 2xx 响应错误
+type HTTPError struct {
+	StatusCode int         // 状态码
+	Status     string      // 状态 如 "404 Not Found"
+	Method     string      // 请求方法
+	URL        string      // 请求地址（已隐藏密码）
+	Header     http.Header // 响应头
+	Body       []byte      // 响应内容片段（最多 4KiB）
+}
+
+// newHTTPError 使用响应生成错误 body 为已读取的响应内容
+func newHTTPError(resp *Response, body []byte) HTTPError {
+	e := HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+	}
+	if len(body) > httpErrorBodySize {
+		body = body[:httpErrorBodySize]
+	}
+	e.Body = append([]byte(nil), body...)
+
+	if req := resp.Request; req != nil {
+		e.Method = req.Method
+		if req.URL != nil {
+			e.URL = req.URL.Redacted()
+		}
+	}
+
+	return e
+}
+
+func (e HTTPError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("http status code unsuccessful response: %s", e.Status)
+	}
+
+	return fmt.Sprintf("%s %s: http status code unsuccessful response: %s", e.Method, e.URL, e.Status)
+}
+
+// As 支持 errors.As(err, &*HTTPError)
+func (e HTTPError) As(target interface{}) bool {
+	if p, ok := target.(**HTTPError); ok {
+		*p = &e
+		return true
+	}
+
+	return false
+}
+
+// ErrorOnStatus 非 2xx 响应时返回 HTTPError （响应 body 将只保留错误中的片段）
+func (c Client) ErrorOnStatus() *Client {
+	c.FailOnStatus = true
+	return &c
+}
+
+// DeleteErrorOnStatus 取消 ErrorOnStatus
+func (c Client) DeleteErrorOnStatus() *Client {
+	c.FailOnStatus = false
+	return &c
+}
+
+// statusError 读取响应片段并生成错误
+func statusError(resp *Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, httpErrorBodySize))
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return newHTTPError(resp, body)
+}
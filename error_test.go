@@ -0,0 +1,38 @@
+package httpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorOnStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("niconiconi"))
+	}))
+	defer srv.Close()
+
+	_, err := ErrorOnStatus().Get(srv.URL + "/?token=123")
+	if !errors.As(err, &HTTPError{}) {
+		t.Fatalf("应该返回 HTTPError: %v", err)
+	}
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatal("应该支持 *HTTPError")
+	}
+	if httpErr.StatusCode != http.StatusInternalServerError || httpErr.Method != http.MethodGet || string(httpErr.Body) != "niconiconi" {
+		t.Errorf("错误信息不一致: %+v", httpErr)
+	}
+
+	// 未设置时只有读取响应才会返回错误
+	res, err := Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, err := res.Bytes(); !errors.As(err, &HTTPError{}) || string(body) != "niconiconi" {
+		t.Error("Bytes 未返回 HTTPError")
+	}
+}
@@ -0,0 +1,176 @@
+package httpc
+
+import (
+	"fmt"
+	"io"
+	stdURL "net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// FilePath 文件路径 作为表单 struct 的文件字段时会在发送时打开该文件
+type FilePath string
+
+var (
+	readerType   = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	filePathType = reflect.TypeOf(FilePath(""))
+)
+
+// SetFromDataStruct 以 struct 设置表单数据 （数据会被读取到内存中）
+//
+// 普通字段使用 form 标签（规则与 SetURLQueryStruct 相同），文件字段使用 file 标签 如 `file:"avatar"`
+// 文件字段的类型可以为 *os.File、FilePath 或 io.Reader（以及它们的切片） 值为空时忽略
+func (c Client) SetFromDataStruct(v interface{}) *Client {
+	rows, err := structFromDataRows(v)
+	if err != nil {
+		return c.handleError(err)
+	}
+
+	return c.SetFromData(rows...)
+}
+
+// SetFromDataStructStream 以 struct 设置表单数据 并以流的方式发送 （规则与 SetFromDataStruct 相同）
+func (c Client) SetFromDataStructStream(v interface{}) *Client {
+	rows, err := structFromDataRows(v)
+	if err != nil {
+		return c.handleError(err)
+	}
+
+	return c.SetFromDataStream(rows...)
+}
+
+// SetForm 设置简单表单 （application/x-www-form-urlencoded）
+// 支持 url.Values、map[string]string、map[string][]string 以及使用 form 标签的 struct
+func (c Client) SetForm(v interface{}) *Client {
+	b, err := encodeForm(v)
+	if err != nil {
+		return c.handleError(err)
+	}
+
+	return c.SetContentType(MIMEXWWWFormURLEncoded).SetBody(b)
+}
+
+// structFromDataRows 将 struct 按字段顺序转换为表单数据
+func structFromDataRows(v interface{}) ([]FromDataRow, error) {
+	var rows []FromDataRow
+	err := walkStruct(reflect.ValueOf(v), "form", "", func(field reflect.StructField, fv reflect.Value, name string, opts tagOptions) error {
+		if tag, ok := field.Tag.Lookup("file"); ok {
+			if key, _ := parseTag(tag); key != "" {
+				name = key
+			}
+			files, err := fileRows(name, fv)
+			rows = append(rows, files...)
+			return err
+		}
+
+		if opts.has("omitempty") && isEmptyValue(fv) {
+			return nil
+		}
+		values := stdURL.Values{}
+		if err := encodeQueryValue(fv, "form", name, opts, field.Tag.Get("layout"), values); err != nil {
+			return err
+		}
+		for _, key := range sortedKeys(values) {
+			for _, value := range values[key] {
+				rows = append(rows, FromDataRow{Key: key, Value: value})
+			}
+		}
+		return nil
+	})
+
+	return rows, err
+}
+
+// fileRows 将文件字段转换为表单数据
+func fileRows(key string, fv reflect.Value) ([]FromDataRow, error) {
+	if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Type().Elem().Kind() != reflect.Uint8 {
+		var rows []FromDataRow
+		for i := 0; i < fv.Len(); i++ {
+			row, err := fileRows(key, fv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row...)
+		}
+		return rows, nil
+	}
+
+	if (fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface) && fv.IsNil() {
+		return nil, nil
+	}
+
+	switch {
+	case fv.Type() == filePathType:
+		path := fv.String()
+		if path == "" {
+			return nil, nil
+		}
+		return []FromDataRow{{Key: key, Value: filepath.Base(path), Data: &lazyFile{path: path}}}, nil
+	case fv.Type().Implements(readerType):
+		r := fv.Interface().(io.Reader)
+		name := key
+		if f, ok := r.(*os.File); ok {
+			name = filepath.Base(f.Name())
+		}
+		return []FromDataRow{{Key: key, Value: name, Data: r}}, nil
+	}
+
+	return nil, fmt.Errorf("file %s: unsupported type %s", key, fv.Type())
+}
+
+// sortedKeys 按键名排序
+func sortedKeys(values stdURL.Values) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lazyFile 发送时才打开的文件 每次发送都会打开新的文件（长度在打开时获取）
+// 直接读取时只能读取一次 读取完毕后自动关闭
+type lazyFile struct {
+	path string
+
+	mu   sync.Mutex
+	f    *os.File
+	done bool
+}
+
+func (l *lazyFile) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return 0, io.EOF
+	}
+	if l.f == nil {
+		f, err := os.Open(l.path)
+		if err != nil {
+			return 0, err
+		}
+		l.f = f
+	}
+
+	n, err := l.f.Read(p)
+	if err != nil {
+		l.f.Close()
+		l.f, l.done = nil, true
+	}
+	return n, err
+}
+
+// openData 实现 dataOpener
+func (l *lazyFile) openData() (io.ReadCloser, int64, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size := readerSize(f)
+	return f, size, nil
+}
@@ -0,0 +1,111 @@
+package httpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type formUpload struct {
+	Title  string          `form:"title"`
+	Tags   []string        `form:"tag,omitempty"`
+	Hidden string          `form:"-"`
+	Avatar FilePath        `file:"avatar"`
+	Note   *os.File        `file:"note"`
+	Extra  []FilePath      `file:"extra"`
+	Raw    *strings.Reader `file:"raw"`
+}
+
+func TestSetFromDataStruct(t *testing.T) {
+	dir := t.TempDir()
+	avatar := filepath.Join(dir, "avatar.jpg")
+	if err := ioutil.WriteFile(avatar, []byte("niconiconi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1024); err != nil {
+			t.Error(err)
+			return
+		}
+		if r.FormValue("title") != "foo" || strings.Join(r.MultipartForm.Value["tag"], ",") != "a,b" || r.FormValue("Hidden") != "" {
+			t.Error("表单字段不一致", r.MultipartForm.Value)
+		}
+		if len(r.MultipartForm.File["extra"]) != 2 || len(r.MultipartForm.File["note"]) != 0 {
+			t.Error("文件数量不一致", r.MultipartForm.File)
+		}
+
+		file, header, err := r.FormFile("avatar")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, _ := ioutil.ReadAll(file)
+		if string(b) != "niconiconi" || header.Filename != "avatar.jpg" {
+			t.Errorf("文件内容不一致: %q %q", b, header.Filename)
+		}
+		if _, header, err := r.FormFile("raw"); err != nil || header.Filename != "raw" {
+			t.Error("io.Reader 字段未上传", err)
+		}
+	}))
+	defer srv.Close()
+
+	upload := func() formUpload {
+		return formUpload{Title: "foo", Tags: []string{"a", "b"}, Hidden: "bar", Avatar: FilePath(avatar), Extra: []FilePath{FilePath(avatar), FilePath(avatar)}, Raw: strings.NewReader("raw")}
+	}
+
+	for _, c := range []*Client{SetFromDataStruct(upload()), SetFromDataStructStream(upload()).SetRetry(RetryPolicy{})} {
+		res, err := c.Post(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	if c := SetFromDataStruct(formUpload{Avatar: FilePath(filepath.Join(dir, "none"))}); c.Error == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+	if _, err := SetFromDataStructStream(formUpload{Avatar: FilePath(filepath.Join(dir, "none"))}).Post(srv.URL); err == nil {
+		t.Error("流式发送时文件不存在应返回错误")
+	}
+
+	// 设置后修改文件 发送时应使用新的长度
+	if err := ioutil.WriteFile(avatar, []byte("nico"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := SetFromDataStructStream(upload())
+	if err := ioutil.WriteFile(avatar, []byte("niconiconi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.Post(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
+
+func TestSetForm(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderContentType) != MIMEXWWWFormURLEncoded {
+			t.Error("Content-Type 不一致", r.Header.Get(HeaderContentType))
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	res, err := SetForm(&struct {
+		Name string `form:"name"`
+		Age  int    `form:"age,omitempty"`
+	}{Name: "elissa"}).Post(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Text(); text != "name=elissa" {
+		t.Error("表单编码结果不一致", text)
+	}
+}
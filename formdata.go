@@ -0,0 +1,426 @@
+package httpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FromDataRow formData 单条数据
+type FromDataRow struct {
+	Key   string    // 键
+	Value string    // 值 （如果上传的是文件则这里是文件名）
+	Data  io.Reader // 数据 （如果上传的是文件则这里是文件不然则为空）
+
+	ContentType string               // 内容类型 （文件默认为 application/octet-stream）
+	Header      textproto.MIMEHeader // 额外的 part header （会覆盖同名的默认值）
+	Size        int64                // 数据长度 （为 0 时自动获取 获取不到则视为未知）
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// partHeader 生成 part header
+func (r FromDataRow) partHeader() textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	if r.Data != nil {
+		h.Set(HeaderContentDisposition, fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(r.Key), quoteEscaper.Replace(r.Value)))
+		h.Set(HeaderContentType, "application/octet-stream")
+	} else {
+		h.Set(HeaderContentDisposition, fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(r.Key)))
+	}
+	if r.ContentType != "" {
+		h.Set(HeaderContentType, r.ContentType)
+	}
+	for k, v := range r.Header {
+		h[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+
+	return h
+}
+
+// SetFromData 设置表单数据 （数据会被读取到内存中）
+func (c Client) SetFromData(rows ...FromDataRow) *Client {
+	if rows == nil {
+		return &c
+	}
+
+	buf := &bytes.Buffer{}
+	mp := multipart.NewWriter(buf)
+	err := writeFromData(mp, rows, func(i int) (io.Reader, func(), error) {
+		if opener, ok := rows[i].Data.(dataOpener); ok {
+			rc, _, err := opener.openData()
+			if err != nil {
+				return nil, nil, err
+			}
+			return rc, func() { rc.Close() }, nil
+		}
+		return rows[i].Data, func() {}, nil
+	})
+	if err != nil {
+		return c.handleError(err)
+	}
+
+	// 终止写入不然对方会报部分上传
+	if err := mp.Close(); err != nil {
+		return c.handleError(err)
+	}
+
+	return c.SetContentType(mp.FormDataContentType()).SetBody(buf)
+}
+
+// SetFromDataStream 以流的方式设置表单数据 （发送请求时才读取数据 不占用额外内存）
+// 所有数据长度已知时会设置 Content-Length，数据均可 Seek 时支持重复发送（如重试）
+// bytes.Reader strings.Reader 以及 FilePath 可以同时发送多个请求，其它可 Seek 的数据（如 *os.File）共用同一个读取位置 同一时间只能发送一个请求
+func (c Client) SetFromDataStream(rows ...FromDataRow) *Client {
+	if rows == nil {
+		return &c
+	}
+
+	body := newMultipartBody(rows)
+	return c.SetContentType("multipart/form-data; boundary=" + body.boundary).SetBody(body)
+}
+
+// dataOpener 每次发送时都重新打开的数据（如 FilePath）
+type dataOpener interface {
+	io.Reader
+	openData() (io.ReadCloser, int64, error) // 返回新的读取器以及长度（-1 为未知）
+}
+
+// writeFromData 写入所有表单数据 data 返回第 i 行的数据以及读取完毕后的清理函数
+func writeFromData(mp *multipart.Writer, rows []FromDataRow, data func(i int) (io.Reader, func(), error)) error {
+	for i, v := range rows {
+		w, err := mp.CreatePart(v.partHeader())
+		if err != nil {
+			return err
+		}
+
+		if v.Data != nil {
+			var reader io.Reader
+			var done func()
+			if reader, done, err = data(i); err != nil {
+				return err
+			}
+			_, err = io.Copy(w, reader)
+			done()
+		} else {
+			_, err = io.WriteString(w, v.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// multipartBody 流式表单 body
+type multipartBody struct {
+	boundary string
+	rows     []FromDataRow
+	starts   []int64 // 数据起始位置（-1 为无法定位）
+	sizes    []int64 // 数据长度（-1 为未知）
+
+	mu     sync.Mutex
+	reader io.Reader // 直接调用 Read 时使用
+}
+
+// multipartWriter 写入 pipe 的协程
+type multipartWriter struct {
+	reader *io.PipeReader
+	done   chan struct{}
+}
+
+func newMultipartBody(rows []FromDataRow) *multipartBody {
+	b := &multipartBody{
+		boundary: multipart.NewWriter(nil).Boundary(),
+		rows:     append([]FromDataRow(nil), rows...),
+		starts:   make([]int64, len(rows)),
+		sizes:    make([]int64, len(rows)),
+	}
+
+	for i, v := range b.rows {
+		b.starts[i], b.sizes[i] = -1, -1
+		switch data := v.Data.(type) {
+		case nil:
+			continue
+		case dataOpener: // 每次发送时重新打开 长度在打开时获取
+			b.starts[i] = 0
+			if v.Size > 0 {
+				b.sizes[i] = v.Size
+			}
+			continue
+		case sizedReaderAt:
+			b.starts[i], b.sizes[i] = data.Size()-int64(data.Len()), int64(data.Len())
+		case io.Seeker:
+			if offset, err := data.Seek(0, io.SeekCurrent); err == nil {
+				b.starts[i] = offset
+			}
+		}
+
+		if v.Size > 0 {
+			b.sizes[i] = v.Size
+		} else if b.sizes[i] < 0 {
+			b.sizes[i] = readerSize(v.Data)
+		}
+	}
+
+	return b
+}
+
+func (b *multipartBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	if b.reader == nil {
+		b.reader = b.pipe(b.sizes, nil).reader
+	}
+	reader := b.reader
+	b.mu.Unlock()
+
+	return reader.Read(p)
+}
+
+// newBody 实现 bodyOpener
+func (b *multipartBody) newBody() (*requestBody, error) {
+	// 每次发送的长度可能不同（如文件被修改）所以使用独立的副本
+	sizes := append([]int64(nil), b.sizes...)
+	pending, err := b.openData(sizes) // 首次发送使用的数据 同时用于获取长度
+	if err != nil {
+		return nil, err
+	}
+
+	replayable := b.replayable()
+
+	// 以下状态只属于本次请求 同一个客户端同时发送的多个请求互不影响
+	var mu sync.Mutex
+	var last *multipartWriter // 上一次发送时的写入协程
+	used := false
+
+	return &requestBody{size: b.contentLength(sizes), replayable: replayable, open: func() (io.Reader, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if used && !replayable {
+			return nil, errNotReplayable
+		}
+		used = true
+
+		if b.seekable() {
+			// 上一次发送可能提前结束（如服务端未读取 body 就返回了响应）
+			// 必须等待其写入协程退出后才能重新定位数据 否则会与其同时读取
+			if last != nil {
+				last.stop()
+			}
+			if err := b.rewind(); err != nil {
+				return nil, err
+			}
+		}
+
+		opened := pending
+		pending = nil
+		last = b.pipe(sizes, opened)
+		return last.reader, nil
+	}, release: func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		closeAll(pending)
+		pending = nil
+	}}, nil
+}
+
+// openData 打开所有需要打开的数据 未手动指定长度时更新 sizes
+func (b *multipartBody) openData(sizes []int64) ([]io.ReadCloser, error) {
+	var opened []io.ReadCloser
+	for i, v := range b.rows {
+		opener, ok := v.Data.(dataOpener)
+		if !ok {
+			continue
+		}
+
+		rc, size, err := opener.openData()
+		if err != nil {
+			closeAll(opened)
+			return nil, err
+		}
+		if opened == nil {
+			opened = make([]io.ReadCloser, len(b.rows))
+		}
+		opened[i] = rc
+		if v.Size <= 0 {
+			sizes[i] = size
+		}
+	}
+
+	return opened, nil
+}
+
+// closeAll 关闭所有已经打开的数据
+func closeAll(opened []io.ReadCloser) {
+	for _, v := range opened {
+		if v != nil {
+			v.Close()
+		}
+	}
+}
+
+// stop 关闭 pipe 并等待写入协程退出
+func (w *multipartWriter) stop() {
+	w.reader.CloseWithError(errNotReplayable)
+	<-w.done
+}
+
+// pipe 边写边读 opened 为已经打开的数据（为空的会在写入时打开）
+func (b *multipartBody) pipe(sizes []int64, opened []io.ReadCloser) *multipartWriter {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		mp := multipart.NewWriter(pw)
+		err := mp.SetBoundary(b.boundary)
+		if err == nil {
+			err = writeFromData(mp, b.rows, func(i int) (io.Reader, func(), error) {
+				return b.data(i, sizes, opened)
+			})
+		}
+		if err == nil {
+			err = mp.Close()
+		}
+		pw.CloseWithError(err)
+
+		closeAll(opened) // 出错时剩余的数据未被读取
+	}()
+
+	return &multipartWriter{reader: pr, done: done}
+}
+
+// data 获取第 i 行的数据 长度已知时最多读取该长度 保证与 Content-Length 一致
+func (b *multipartBody) data(i int, sizes []int64, opened []io.ReadCloser) (io.Reader, func(), error) {
+	reader := b.rows[i].Data
+	done := func() {}
+
+	if opener, ok := reader.(dataOpener); ok {
+		var rc io.ReadCloser
+		if opened != nil && opened[i] != nil {
+			rc, opened[i] = opened[i], nil
+		} else {
+			var err error
+			if rc, _, err = opener.openData(); err != nil {
+				return nil, nil, err
+			}
+		}
+		reader, done = rc, func() { rc.Close() }
+	} else if v, ok := reader.(sizedReaderAt); ok {
+		return io.NewSectionReader(v, b.starts[i], sizes[i]), done, nil
+	}
+
+	if sizes[i] >= 0 {
+		reader = io.LimitReader(reader, sizes[i])
+	}
+	return reader, done, nil
+}
+
+// replayable 所有数据都可以重新定位时才可以重复读取
+func (b *multipartBody) replayable() bool {
+	for i, v := range b.rows {
+		if v.Data != nil && b.starts[i] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// seekable 是否存在需要重新定位的数据（bytes.Reader 等按偏移读取 FilePath 每次重新打开 都不需要）
+func (b *multipartBody) seekable() bool {
+	for _, v := range b.rows {
+		switch v.Data.(type) {
+		case sizedReaderAt, dataOpener:
+			continue
+		case io.Seeker:
+			return true
+		}
+	}
+	return false
+}
+
+// rewind 将可以 Seek 的数据定位回起始位置
+func (b *multipartBody) rewind() error {
+	for i, v := range b.rows {
+		switch data := v.Data.(type) {
+		case sizedReaderAt, dataOpener:
+			continue
+		case io.Seeker:
+			if _, err := data.Seek(b.starts[i], io.SeekStart); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// contentLength 计算 body 总长度（存在未知长度的数据时返回 -1）
+func (b *multipartBody) contentLength(sizes []int64) int64 {
+	cw := &countWriter{}
+	mp := multipart.NewWriter(cw)
+	if err := mp.SetBoundary(b.boundary); err != nil {
+		return -1
+	}
+
+	for i, v := range b.rows {
+		if v.Data != nil && sizes[i] < 0 {
+			return -1
+		}
+
+		w, err := mp.CreatePart(v.partHeader())
+		if err != nil {
+			return -1
+		}
+		if v.Data != nil {
+			cw.n += sizes[i]
+		} else {
+			io.WriteString(w, v.Value)
+		}
+	}
+	if err := mp.Close(); err != nil {
+		return -1
+	}
+
+	return cw.n
+}
+
+// readerSize 获取剩余数据长度（-1 为未知）
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case sizedReaderAt:
+		return int64(v.Len())
+	case *bytes.Buffer:
+		return int64(v.Len())
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+
+	return -1
+}
+
+// countWriter 只计数的 writer
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,231 @@
+package httpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSetFromDataStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1024); err != nil {
+			t.Error(err)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, _ := ioutil.ReadAll(file)
+		if string(b) != "niconiconi" || header.Filename != "nico.txt" {
+			t.Errorf("文件内容不一致: %q %q", b, header.Filename)
+		}
+		if header.Header.Get(HeaderContentType) != MIMETextPlain || header.Header.Get("X-Foo") != "bar" {
+			t.Error("自定义 part header 未生效")
+		}
+		if r.FormValue("name") != "elissa" {
+			t.Error("表单字段不一致")
+		}
+		w.Header().Set("X-Content-Length", r.Header.Get("Content-Length"))
+	}))
+	defer srv.Close()
+
+	rows := func(data string) []FromDataRow {
+		return []FromDataRow{
+			{Key: "name", Value: "elissa"},
+			{Key: "file", Value: "nico.txt", Data: strings.NewReader(data), ContentType: MIMETextPlain, Header: textproto.MIMEHeader{"X-Foo": {"bar"}}},
+		}
+	}
+
+	// 长度已知时应与缓存到内存中的长度一致
+	buffered, err := SetFromData(rows("niconiconi")...).Post(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffered.Body.Close()
+
+	res, err := SetFromDataStream(rows("niconiconi")...).Post(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.Header.Get("X-Content-Length") == "" || res.Header.Get("X-Content-Length") != buffered.Header.Get("X-Content-Length") {
+		t.Errorf("Content-Length 计算错误: %s != %s", res.Header.Get("X-Content-Length"), buffered.Header.Get("X-Content-Length"))
+	}
+
+	// 长度未知时使用分块传输
+	unknown := rows("")
+	unknown[1].Data = ioutil.NopCloser(strings.NewReader("niconiconi"))
+	res, err = SetFromDataStream(unknown...).Post(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.Header.Get("X-Content-Length") != "" {
+		t.Error("长度未知时不应设置 Content-Length")
+	}
+}
+
+func TestSetFromDataStreamReplay(t *testing.T) {
+	data := bytes.Repeat([]byte("niconiconi"), 512*1024)
+	path := filepath.Join(t.TempDir(), "data")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次不读取 body 直接返回 传输层会提前结束发送
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Connection", "close")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, _ := ioutil.ReadAll(file)
+		if !bytes.Equal(b, data) {
+			t.Error("重新发送的数据不一致", len(b))
+		}
+	}))
+	defer srv.Close()
+
+	res, err := SetFromDataStream(FromDataRow{Key: "file", Value: "data", Data: f}).
+		SetRetry(RetryPolicy{Backoff: ConstantBackoff(0)}).Post(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || atomic.LoadInt32(&attempts) != 2 {
+		t.Error("应重试一次", res.StatusCode, attempts)
+	}
+}
+
+func TestSetFromDataStreamSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, _ := ioutil.ReadAll(file)
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	// 声明的长度小于实际数据时只发送声明的长度
+	res, err := SetFromDataStream(FromDataRow{Key: "file", Value: "data", Data: ioutil.NopCloser(strings.NewReader("niconiconi")), Size: 4}).Post(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Text(); text != "nico" {
+		t.Errorf("数据长度与声明的不一致: %q", text)
+	}
+}
+
+func TestSetFromDataStreamConcurrent(t *testing.T) {
+	data := bytes.Repeat([]byte("niconiconi"), 64*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, _ := ioutil.ReadAll(file)
+		if !bytes.Equal(b, data) {
+			t.Error("数据不一致", len(b))
+		}
+	}))
+	defer srv.Close()
+
+	// 共享的基础客户端可以同时发送多个请求
+	base := SetFromDataStream(FromDataRow{Key: "file", Value: "data", Data: bytes.NewReader(data)})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := base.Post(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res.Body.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+// countingOpener 记录打开以及关闭次数的 dataOpener
+type countingOpener struct {
+	opened, closed int32
+}
+
+func (o *countingOpener) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (o *countingOpener) openData() (io.ReadCloser, int64, error) {
+	atomic.AddInt32(&o.opened, 1)
+	return countingCloser{Reader: strings.NewReader("niconiconi"), closed: &o.closed}, 10, nil
+}
+
+type countingCloser struct {
+	io.Reader
+	closed *int32
+}
+
+func (c countingCloser) Close() error {
+	atomic.AddInt32(c.closed, 1)
+	return nil
+}
+
+func TestSetFromDataStreamAbort(t *testing.T) {
+	before := runtime.NumGoroutine()
+	opener := &countingOpener{}
+	client := SetFromDataStream(FromDataRow{Key: "file", Value: "data", Data: opener})
+
+	// 请求在交给 Transport 之前失败 写入协程应退出 打开的数据应关闭
+	aborted := client.OnRequest(func(req *http.Request) error {
+		return errors.New("abort")
+	})
+	for i := 0; i < 50; i++ {
+		if _, err := aborted.Post("http://127.0.0.1:1"); err == nil {
+			t.Fatal("应返回拦截器的错误")
+		}
+	}
+	if !waitGoroutines(before) {
+		t.Errorf("协程泄露: %d -> %d", before, runtime.NumGoroutine())
+	}
+
+	// 还未设置到请求上就失败
+	if _, err := client.CallWithContext(context.Background(), "BAD METHOD", "http://127.0.0.1:1"); err == nil {
+		t.Fatal("错误的请求方法应返回错误")
+	}
+
+	if opened, closed := atomic.LoadInt32(&opener.opened), atomic.LoadInt32(&opener.closed); opened != 51 || closed != opened {
+		t.Errorf("打开 %d 次 关闭 %d 次", opened, closed)
+	}
+}
@@ -1,3 +1,9 @@
 module github.com/elissa2333/httpc
 
-go 1.15
+go 1.18
+
+require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/klauspost/compress v1.17.2
+	golang.org/x/net v0.25.0
+)
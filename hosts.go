@@ -0,0 +1,198 @@
+package httpc
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHosts 解析器默认使用的系统 hosts 文件（不会自动重新加载）
+var DefaultHosts = NewHosts(systemHostsPath(), 0)
+
+// systemHostsPath 系统 hosts 文件路径
+func systemHostsPath() string {
+	if runtime.GOOS == "windows" {
+		root := os.Getenv("SystemRoot")
+		if root == "" {
+			root = `C:\Windows`
+		}
+		return filepath.Join(root, "System32", "drivers", "etc", "hosts")
+	}
+
+	return "/etc/hosts"
+}
+
+// Hosts hosts 文件（并发安全）
+type Hosts struct {
+	path           string
+	reloadInterval time.Duration
+
+	mu      sync.RWMutex
+	loaded  bool
+	checked time.Time // 上次检查文件的时间
+	modTime time.Time
+	size    int64
+	entries map[string][]net.IP
+}
+
+// NewHosts 新建 hosts 文件（第一次查询时读取）
+// reloadInterval 大于 0 时 查询时最多每隔该时间检查一次文件 文件发生变化时重新加载
+func NewHosts(path string, reloadInterval time.Duration) *Hosts {
+	return &Hosts{path: path, reloadInterval: reloadInterval}
+}
+
+// Lookup 查询域名对应的所有地址 不存在时返回 nil
+func (h *Hosts) Lookup(host string) []net.IP {
+	h.refresh()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.entries[normalizeHost(host)]
+}
+
+// refresh 首次查询或文件发生变化时加载文件
+func (h *Hosts) refresh() {
+	now := time.Now()
+
+	h.mu.RLock()
+	fresh := h.loaded && (h.reloadInterval <= 0 || now.Sub(h.checked) < h.reloadInterval)
+	h.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.loaded && (h.reloadInterval <= 0 || now.Sub(h.checked) < h.reloadInterval) { // 其它协程已经加载过
+		return
+	}
+	h.checked = now
+
+	info, err := os.Stat(h.path)
+	if err != nil {
+		h.loaded, h.entries, h.modTime, h.size = true, nil, time.Time{}, 0
+		return
+	}
+	if h.loaded && info.ModTime().Equal(h.modTime) && info.Size() == h.size {
+		return
+	}
+
+	f, err := os.Open(h.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	h.loaded, h.entries, h.modTime, h.size = true, parseHosts(f), info.ModTime(), info.Size()
+}
+
+// parseHosts 解析 hosts 文件内容（支持行尾注释以及 IPv6 地址）
+func parseHosts(r io.Reader) map[string][]net.IP {
+	entries := map[string][]net.IP{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		if ip == nil { // 带 zone 的地址无法使用 net.IP 表示
+			continue
+		}
+
+		for _, name := range fields[1:] {
+			name = normalizeHost(name)
+			entries[name] = append(entries[name], ip)
+		}
+	}
+
+	return entries
+}
+
+// normalizeHost 域名不区分大小写 并去掉末尾的点
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// SetHostOverrides 设置域名对应的地址（类似 curl 的 --resolve）不会经过 dns 解析
+// 键为 host 或 host:port（只对该端口生效），值为一个或多个以逗号分隔的 IP
+// 设置了代理时连接的是代理服务器 覆盖只对代理服务器的地址生效（目标域名由代理解析）
+// 会创建新的 Transport（独立的连接池）
+func (c Client) SetHostOverrides(overrides map[string]string) *Client {
+	out := make(map[string]string, len(c.HostOverrides)+len(overrides))
+	for k, v := range c.HostOverrides {
+		out[k] = v
+	}
+	for k, v := range overrides {
+		if _, err := parseIPList(v); err != nil {
+			return c.handleError(err)
+		}
+		out[overrideKey(k)] = v
+	}
+
+	c.HostOverrides = out
+	return c.applyDialer()
+}
+
+// DeleteHostOverrides 删除所有域名地址覆盖
+func (c Client) DeleteHostOverrides() *Client {
+	c.HostOverrides = nil
+	return c.applyDialer()
+}
+
+// overrideKey 规范化覆盖的键 只规范化 host:port 中的 host 部分
+func overrideKey(key string) string {
+	host, port, err := net.SplitHostPort(key)
+	if err != nil { // 没有端口
+		return normalizeHost(key)
+	}
+
+	return net.JoinHostPort(normalizeHost(host), port)
+}
+
+// lookupOverride 查询 host:port 的覆盖地址
+func lookupOverride(overrides map[string]string, host, port string) []net.IP {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	host = normalizeHost(host)
+	v, ok := overrides[net.JoinHostPort(host, port)]
+	if !ok {
+		v, ok = overrides[host]
+	}
+	if !ok {
+		return nil
+	}
+
+	ips, _ := parseIPList(v)
+	return ips
+}
+
+// parseIPList 解析以逗号分隔的 IP
+func parseIPList(list string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, v := range strings.Split(list, ",") {
+		v = strings.Trim(strings.TrimSpace(v), "[]")
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: v}
+		}
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
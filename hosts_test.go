@@ -0,0 +1,99 @@
+package httpc
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHosts(t *testing.T) {
+	entries := parseHosts(strings.NewReader(`# comment
+127.0.0.1	localhost foo.test # trailing comment
+::1 localhost
+192.168.0.1 Bar.Test.
+fe80::1%lo0 zone.test
+#192.168.0.2 commented.test
+invalid line.test
+`))
+
+	want := map[string][]net.IP{
+		"localhost": {net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		"foo.test":  {net.ParseIP("127.0.0.1")},
+		"bar.test":  {net.ParseIP("192.168.0.1")},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("解析结果为 %v 期望 %v", entries, want)
+	}
+}
+
+func TestHostsReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := ioutil.WriteFile(path, []byte("127.0.0.1 foo.test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	static, reload := NewHosts(path, 0), NewHosts(path, time.Nanosecond)
+	for _, h := range []*Hosts{static, reload} {
+		if ips := h.Lookup("FOO.test"); len(ips) != 1 || !ips[0].Equal(net.ParseIP("127.0.0.1")) {
+			t.Error("查询结果不一致", ips)
+		}
+	}
+
+	if err := ioutil.WriteFile(path, []byte("127.0.0.2 foo.test\n::1 bar.test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+
+	if ips := static.Lookup("foo.test"); len(ips) != 1 || !ips[0].Equal(net.ParseIP("127.0.0.1")) {
+		t.Error("未开启重新加载时不应重新读取文件", ips)
+	}
+	if ips := reload.Lookup("foo.test"); len(ips) != 1 || !ips[0].Equal(net.ParseIP("127.0.0.2")) {
+		t.Error("文件变化后应重新加载", ips)
+	}
+
+	r, _ := NewResolver()
+	r.Hosts = reload
+	if ips, err := r.LookupIP(context.Background(), "bar.test"); err != nil || len(ips) != 1 || !ips[0].Equal(net.ParseIP("::1")) {
+		t.Error("解析器应优先使用 hosts", ips, err)
+	}
+
+	if ips := NewHosts(filepath.Join(t.TempDir(), "none"), 0).Lookup("foo.test"); ips != nil {
+		t.Error("文件不存在时应返回空")
+	}
+}
+
+func TestSetHostOverrides(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	for _, key := range []string{"example.test", "Example.Test:" + port, "example.test.:" + port} {
+		res, err := SetHostOverrides(map[string]string{key: "::1, 127.0.0.1"}).Get("http://example.test:" + port)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Text() != "example.test:"+port {
+			t.Error("Host 应保持不变")
+		}
+	}
+
+	// 端口不一致时不生效
+	if _, err := SetHostOverrides(map[string]string{"example.test:1": "127.0.0.1"}).UseDNS("127.0.0.1:1").Get("http://example.test:" + port); err == nil {
+		t.Error("指定端口的覆盖不应对其它端口生效")
+	}
+
+	if c := SetHostOverrides(map[string]string{"example.test": "example.com"}); c.Error == nil {
+		t.Error("错误的地址应返回错误")
+	}
+}
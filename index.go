@@ -7,15 +7,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
-	"mime/multipart"
-	"net"
 	"net/http"
 	stdURL "net/url"
-	"os"
 	"reflect"
-	"runtime"
 	"strings"
 	"time"
 )
@@ -75,12 +70,33 @@ func (e *LinkError) Unwrap() error {
 
 // Client 客户端
 type Client struct {
-	BaseURL  string        // 基础 URL
-	URLQuery stdURL.Values // URL 查询参数
+	BaseURL          string            // 基础 URL
+	PreserveBasePath bool              // 解析相对地址时保留基础 URL 的路径（默认按 RFC 3986 解析）
+	URLQuery         stdURL.Values     // URL 查询参数
+	PathParams       map[string]string // 路径参数
 
-	Headers map[string]string // 头部信息
-	Body    io.Reader         // 内容
-	Client  http.Client       // 客户端
+	Headers http.Header    // 头部信息
+	Cookies []*http.Cookie // 请求时携带的 cookie
+	Body    io.Reader      // 内容
+	Client  http.Client    // 客户端
+
+	Resolver      *Resolver         // 域名解析器
+	AddressFamily AddressFamily     // 连接使用的地址族
+	HostOverrides map[string]string // 域名对应的地址（不经过 dns 解析）
+
+	Retry       *RetryPolicy  // 重试策略
+	Middlewares []Middleware  // 中间件
+	Auth        Authenticator // 认证方式
+	Signer      Signer        // 请求签名
+
+	BodyCompression string // 请求 body 压缩方式
+
+	UploadProgress   ProgressFunc // 上传进度回调
+	DownloadProgress ProgressFunc // 下载进度回调
+
+	FailOnStatus bool        // 非 2xx 响应时返回 HTTPError
+	Result       interface{} // 成功时解析响应的目标
+	ErrorResult  interface{} // 失败时解析响应的目标
 
 	Error *LinkError // 错误
 }
@@ -97,87 +113,26 @@ func UseClient(httpClient http.Client) *Client {
 	}
 }
 
-// UseDNS 使用指定 dns 解析域名
-func (c Client) UseDNS(dns ...string) *Client {
-	tr := &http.Transport{}
-	if c.Client.Transport != nil {
-		current, ok := c.Client.Transport.(*http.Transport)
-		if ok {
-			tr = current
-		}
-	}
-
-	dialer := net.Dialer{}
-	tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
-		host, port, err := net.SplitHostPort(addr)
-		if err != nil {
-			return nil, err
-		}
-		addrs, err := dnsResolver(host, dns...)
-		if err != nil {
-			return nil, err
-		}
-		if len(addrs) != 0 {
-			addr = net.JoinHostPort(addrs[0], port)
-		}
-		return dialer.DialContext(ctx, network, addr)
-	}
-
-	c.Client.Transport = tr
-
-	return &c
-}
-
-var systemHosts map[string]string
-
-func useHostsPsrseIP(host string) string {
-	if systemHosts == nil {
-		hostsPATH := "/etc/hosts"
-		switch runtime.GOOS {
-		case "windows":
-			hostsPATH = "/windows/system32/drivers/etc/hosts"
-		}
-
-		b, err := os.ReadFile(hostsPATH)
-		if err != nil {
-			return ""
-		}
-
-		systemHosts = map[string]string{}
-
-		for _, v := range strings.Split(string(b), "\n") {
-			if v != "" && !strings.ContainsRune(v, '#') {
-				sp := strings.Fields(v)
-				if len(sp) < 2 {
-					continue
-				}
-
-				for _, v := range sp[1:] {
-					systemHosts[v] = sp[0]
-				}
-			}
-		}
+// transport 复制一份当前使用的 *http.Transport（修改时不会影响到其它链）
+// 复制后的 Transport 拥有独立的连接池 不会复用之前的连接 旧连接在空闲超时后才会关闭
+func (c Client) transport() *http.Transport {
+	if current, ok := c.Client.Transport.(*http.Transport); ok && current != nil {
+		return current.Clone()
 	}
 
-	return systemHosts[host]
+	return &http.Transport{}
 }
 
 // SetProxy 设置代理
+// 会创建新的 Transport（独立的连接池）请保存返回的客户端复用 不要在每次请求时调用
 func (c Client) SetProxy(proxy string) *Client {
-	tr := &http.Transport{}
-
-	if c.Client.Transport != nil {
-		current, ok := c.Client.Transport.(*http.Transport)
-		if ok {
-			tr = current
-		}
-	}
-
 	p, err := stdURL.Parse(proxy)
 	if err != nil {
 		return c.handleError(err)
 	}
 
+	tr := c.transport()
+
 	tr.Proxy = http.ProxyURL(p)
 
 	c.Client.Transport = tr
@@ -187,14 +142,17 @@ func (c Client) SetProxy(proxy string) *Client {
 
 // DeleteProxy 删除代理
 func (c Client) DeleteProxy() *Client {
-	tr, ok := c.Client.Transport.(*http.Transport)
-	if !ok {
-		c.handleError(errors.New("client Transport interface type is not a *http.Transport"))
+	if c.Client.Transport == nil {
+		return &c
 	}
-	if tr != nil {
-		tr.Proxy = nil
+	if _, ok := c.Client.Transport.(*http.Transport); !ok {
+		return c.handleError(errors.New("client Transport interface type is not a *http.Transport"))
 	}
 
+	tr := c.transport()
+	tr.Proxy = nil
+	c.Client.Transport = tr
+
 	return &c
 }
 
@@ -204,8 +162,18 @@ func (c Client) SetTimeout(duration time.Duration) *Client {
 	return &c
 }
 
-// SetBaseURL 设置基础 URL 后续访问将默认拼接本URL
+// SetBaseURL 设置基础 URL 后续访问的相对地址将基于本 URL 进行解析（绝对地址不受影响）
 func (c Client) SetBaseURL(url string) *Client {
+	if url == "" { // 如 SetBaseURL(os.Getenv("API_BASE")) 未设置环境变量时
+		return c.DeleteBaseURL()
+	}
+	// 包含模板表达式时在发送时展开后再校验
+	if !strings.Contains(url, "{") {
+		if _, err := parseBaseURL(url); err != nil {
+			return c.handleError(err)
+		}
+	}
+
 	c.BaseURL = url
 	return &c
 }
@@ -220,7 +188,8 @@ func (c Client) DeleteBaseURL() *Client {
 func (c Client) AddURLQuery(key string, values ...string) *Client {
 	v, ok := c.URLQuery[key]
 	if ok {
-		c.URLQuery[key] = append(v, values...)
+		c.URLQuery = cloneValues(c.URLQuery)
+		c.URLQuery[key] = append(append([]string(nil), v...), values...)
 		return &c
 	}
 
@@ -241,15 +210,31 @@ func (c Client) AddURLQueryS(query string) *Client {
 
 // SetURLQuery 设置 URL 查询参数
 func (c Client) SetURLQuery(key string, values ...string) *Client {
+	values = append([]string(nil), values...)
 	if c.URLQuery == nil {
 		c.URLQuery = stdURL.Values{key: values}
 	} else {
+		c.URLQuery = cloneValues(c.URLQuery)
 		c.URLQuery[key] = values
 	}
 
 	return &c
 }
 
+// cloneValues 复制查询参数
+func cloneValues(values stdURL.Values) stdURL.Values {
+	if values == nil {
+		return nil
+	}
+
+	out := make(stdURL.Values, len(values))
+	for k, v := range values {
+		out[k] = append([]string(nil), v...)
+	}
+
+	return out
+}
+
 // SetURLQueryS 以 string 的方式设置查询参数
 func (c Client) SetURLQueryS(query string) *Client {
 	values, err := stdURL.ParseQuery(query)
@@ -267,13 +252,27 @@ func (c Client) DeleteURLQuery() *Client {
 	return &c
 }
 
-// SetHeader 设置 header 字段
+// SetHeader 设置 header 字段（会覆盖已有的值 键名不区分大小写）
 func (c Client) SetHeader(key, value string) *Client {
+	c.Headers = c.Headers.Clone()
 	if c.Headers == nil {
-		c.Headers = map[string]string{}
+		c.Headers = http.Header{}
 	}
 
-	c.Headers[key] = value
+	c.Headers.Set(key, value)
+	return &c
+}
+
+// AddHeader 添加 header 字段（已有的值会保留 同一个键可以发送多次）
+func (c Client) AddHeader(key string, values ...string) *Client {
+	c.Headers = c.Headers.Clone()
+	if c.Headers == nil {
+		c.Headers = http.Header{}
+	}
+
+	for _, v := range values {
+		c.Headers.Add(key, v)
+	}
 	return &c
 }
 
@@ -289,8 +288,9 @@ func (c Client) SetHeaders(headers map[string]string) *Client {
 
 // DeleteHeaders 删除指定 headers
 func (c Client) DeleteHeaders(keys ...string) *Client {
+	c.Headers = c.Headers.Clone()
 	for _, v := range keys {
-		delete(c.Headers, v)
+		c.Headers.Del(v)
 	}
 
 	return &c
@@ -306,12 +306,22 @@ func (c Client) SetContentType(contentType string) *Client {
 	return c.SetHeader(HeaderContentType, contentType)
 }
 
+// SetAccept 设置可接受的内容类型 不传参数时为所有已注册解码器支持的类型
+func (c Client) SetAccept(mediaTypes ...string) *Client {
+	if len(mediaTypes) == 0 {
+		mediaTypes = decoderMediaTypes()
+	}
+
+	return c.SetHeader(HeaderAccept, strings.Join(mediaTypes, ", "))
+}
+
 func (c Client) setBody(body io.Reader) *Client {
 	c.Body = body
 	return &c
 }
 
 // SetBody 设置内容
+// struct map slice 会按 Content-Type 选择编码器进行编码（未设置时使用 json），url.Values 会编码为简单表单
 func (c Client) SetBody(body interface{}) *Client {
 	switch value := body.(type) {
 	case io.Reader:
@@ -320,112 +330,121 @@ func (c Client) SetBody(body interface{}) *Client {
 		return c.setBody(bytes.NewReader(value))
 	case string:
 		return c.setBody(strings.NewReader(value))
-	case nil:
-		return &c
-	}
-
-	refType := reflect.TypeOf(body)
-	if refType.Kind() == reflect.Ptr {
-		refType = refType.Elem()
-	}
-	switch refType.Kind() {
-	case reflect.Struct, reflect.Map, reflect.Slice:
-		b, err := json.Marshal(body)
-		if err != nil {
-			return c.handleError(err)
-		}
-
+	case stdURL.Values:
 		this := &c
-		if c.Headers[HeaderContentType] == "" {
-			this = this.SetContentType(MIMEApplicationJSON)
+		if c.Headers.Get(HeaderContentType) == "" {
+			this = this.SetContentType(MIMEXWWWFormURLEncoded)
 		}
-		return this.setBody(bytes.NewReader(b))
-	}
-
-	return c.handleError(errors.New("no content to set"))
-}
-
-// FromDataRow formData 单条数据
-type FromDataRow struct {
-	Key   string    // 键
-	Value string    // 值 （如果上传的是文件则这里是文件名）
-	Data  io.Reader // 数据 （如果上传的是文件则这里是文件不然则为空）
-}
-
-// SetFromData 设置表单数据
-func (c Client) SetFromData(rows ...FromDataRow) *Client {
-	if rows == nil {
+		return this.setBody(strings.NewReader(value.Encode()))
+	case nil:
 		return &c
 	}
 
-	buf := &bytes.Buffer{}
-	mp := multipart.NewWriter(buf)
-	for _, v := range rows {
-		if v.Data != nil {
-			fw, err := mp.CreateFormFile(v.Key, v.Value)
-			if err != nil {
-				return c.handleError(err)
-			}
-			if _, err := io.Copy(fw, v.Data); err != nil {
-				return c.handleError(err)
-			}
-		} else {
-			fw, err := mp.CreateFormField(v.Key)
-			if err != nil {
-				return c.handleError(err)
-			}
-			if _, err := fw.Write([]byte(v.Value)); err != nil {
-				return c.handleError(err)
-			}
+	contentType := c.Headers.Get(HeaderContentType)
+	encoder, ok := lookupEncoder(contentType)
+	if !ok {
+		refType := reflect.TypeOf(body)
+		if refType.Kind() == reflect.Ptr {
+			refType = refType.Elem()
+		}
+		switch refType.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice:
+			encoder = json.Marshal
+		default:
+			return c.handleError(errors.New("no content to set"))
 		}
 	}
 
-	// 终止写入不然对方会报部分上传
-	if err := mp.Close(); err != nil {
+	b, err := encoder(body)
+	if err != nil {
 		return c.handleError(err)
 	}
 
-	return c.SetContentType(mp.FormDataContentType()).SetBody(buf)
+	this := &c
+	if contentType == "" {
+		this = this.SetContentType(MIMEApplicationJSON)
+	}
+	return this.setBody(bytes.NewReader(b))
 }
 
-// Do 发送自定义请求（无法共享链式调用的数据）
+// Do 发送自定义请求（无法共享链式调用的数据 但会经过中间件）
 func (c Client) Do(req *http.Request) (*Response, error) {
 	if c.Error != nil {
 		return nil, c.Error
 	}
 
-	resp, err := c.Client.Do(req)
-	return &Response{Response: resp}, err
+	return c.handler()(req)
 }
 
 // CallWithContext 使用指定 http 方法访问 url
 func (c Client) CallWithContext(ctx context.Context, method string, url string) (*Response, error) {
-	if c.BaseURL != "" {
-		url = c.BaseURL + url
+	if c.Error != nil {
+		return nil, c.Error
 	}
 
-	if c.URLQuery != nil {
-		parseURL, err := stdURL.Parse(url)
-		if err != nil {
-			return nil, err
-		}
-		withQuery := parseURL.Query()
-		for k, v := range c.URLQuery {
-			for _, j := range v {
-				withQuery.Add(k, j)
-			}
-		}
-		parseURL.RawQuery = withQuery.Encode()
+	url, err := c.buildURL(url)
+	if err != nil {
+		return nil, c.handleError(err).Error
+	}
+
+	body, err := newRequestBody(c.Body, c.replayBody())
+	if err != nil {
+		return nil, err
+	}
+	defer body.close()
+	if c.BodyCompression != "" {
+		body = body.compress(c.BodyCompression)
+	}
+
+	resp, err := c.doWithRetry(ctx, method, url, body)
+	if err != nil {
+		return resp, err
+	}
+
+	if c.DownloadProgress != nil {
+		resp.SetDownloadProgress(c.DownloadProgress)
+	}
+
+	if err := c.decodeResult(resp); err != nil {
+		return resp, err
+	}
+
+	if c.FailOnStatus && !resp.IsSuccessful() {
+		return resp, statusError(resp)
+	}
+
+	return resp, nil
+}
 
-		url = parseURL.String()
+// replayBody 是否需要缓存 body 以便重复发送
+func (c Client) replayBody() bool {
+	if c.Retry != nil || c.Signer != nil {
+		return true
+	}
+	if replayer, ok := c.Auth.(bodyReplayer); ok && replayer.replayBody() {
+		return true
 	}
 
-	req, err := NewRequestWithContext(ctx, method, url, c.Headers, c.Body)
+	return false
+}
+
+// newRequest 构建单次请求
+func (c Client) newRequest(ctx context.Context, method string, url string, body *requestBody) (*http.Request, error) {
+	req, err := NewRequestWithContext(ctx, method, url, c.Headers, nil)
 	if err != nil {
 		return nil, err
 	}
+	for _, v := range c.Cookies {
+		req.AddCookie(v)
+	}
+	if err := body.apply(req); err != nil {
+		return nil, err
+	}
+	if c.BodyCompression != "" && req.Body != nil && req.Body != http.NoBody {
+		req.Header.Set(HeaderContentEncoding, c.BodyCompression)
+	}
 
-	return c.Do(req)
+	return req, nil
 }
 
 // Options 获取目的资源所支持的通信选项
@@ -468,10 +487,10 @@ func (c Client) PostWithContext(ctx context.Context, url string) (*Response, err
 	return c.CallWithContext(ctx, http.MethodPost, url)
 }
 
-//// PostForm http.PostForm
-//func (c Client) PostForm(url string, values url.Values) (*Response, error) {
-//	return c.SetContentType(MIMEXWWWFormURLEncoded).SetBody(values.Encode()).Post(url)
-//}
+// PostForm 以简单表单的方式发送 POST 请求
+func (c Client) PostForm(url string, values stdURL.Values) (*Response, error) {
+	return c.SetContentType(MIMEXWWWFormURLEncoded).SetBody(values.Encode()).Post(url)
+}
 
 // Put 发送 PUT 请求
 func (c Client) Put(url string) (*Response, error) {
@@ -515,74 +534,3 @@ func (c Client) handleError(err error) *Client {
 
 	return &c
 }
-
-func dnsResolver(domain string, dnsList ...string) (addrs []string, err error) {
-	addr := net.ParseIP(domain)
-	if len(addr) != 0 {
-		return []string{domain}, nil
-	}
-	if host := useHostsPsrseIP(domain); host != "" {
-		return []string{host}, nil
-	}
-
-	var dnsErr error
-	var appendErr = func(err error) {
-		if dnsErr == nil {
-			dnsErr = err
-		} else {
-			dnsErr = fmt.Errorf("%w -> %s", dnsErr, err)
-		}
-	}
-	var purifyDNS []string
-	for k, v := range dnsList {
-		if v == "" {
-			continue
-		}
-
-		host, port, err := net.SplitHostPort(v)
-		if err != nil {
-			addrErr, ok := err.(*net.AddrError)
-			if !ok {
-				appendErr(fmt.Errorf("%d: %w", k, err))
-				continue
-			}
-
-			// 端口不存在自动加一个
-			if addrErr.Err == "missing port in address" {
-				host = v
-				port = "53"
-			} else {
-				appendErr(fmt.Errorf("%d: %w", k, err))
-				continue
-			}
-		}
-
-		if host == "" {
-			appendErr(fmt.Errorf("%d: %w", k, errors.New("dns host is empty")))
-			continue
-		}
-
-		purifyDNS = append(purifyDNS, net.JoinHostPort(host, port))
-	}
-	if len(purifyDNS) == 0 {
-		return net.LookupHost(domain)
-	}
-
-	for _, v := range purifyDNS {
-		r := net.DefaultResolver
-		r.PreferGo = true
-		r.Dial = func(ctx context.Context, network, address string) (net.Conn, error) {
-			var d net.Dialer // 官方库就是这样用的
-			return d.DialContext(ctx, network, v)
-		}
-
-		addrs, err := r.LookupHost(context.Background(), domain)
-		if err == nil {
-			return addrs, nil
-		}
-
-		appendErr(fmt.Errorf("%s: %w", v, err))
-	}
-
-	return nil, dnsErr
-}
@@ -2,10 +2,15 @@ package httpc
 
 import (
 	"bytes"
+	"context"
 	"io/ioutil"
+	"net/http"
 	"net/url"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestOneWayCall(t *testing.T) { // 单向测试（所有变量的作用域应该只向后进行泄露）
@@ -31,6 +36,76 @@ func TestOneWayCall(t *testing.T) { // 单向测试（所有变量的作用域
 	if client.Body != nil {
 		t.Error("Body 设置向 this 泄露")
 	}
+
+	client.Use(func(next Handler) Handler { return next })
+	if client.Middlewares != nil {
+		t.Error("Middlewares 设置向 this 泄露")
+	}
+
+	client.SetProxy("http://127.0.0.1:8118")
+	client.UseDNS("127.0.0.1").SetAddressFamily(IPv4Only).SetHostOverrides(map[string]string{"example.com": "127.0.0.1"})
+	if client.Client.Transport != nil || client.Resolver != nil || client.AddressFamily != DualStack || client.HostOverrides != nil {
+		t.Error("Transport 设置向 this 泄露")
+	}
+
+	client.SetRetry(RetryPolicy{}).SetTimeout(time.Second).ErrorOnStatus().SetBodyCompression("gzip")
+	if client.Retry != nil || client.Client.Timeout != 0 || client.FailOnStatus || client.BodyCompression != "" {
+		t.Error("选项设置向 this 泄露")
+	}
+}
+
+func TestCopyOnWrite(t *testing.T) { // 派生链之间不应共享任何可变数据
+	base := SetHeader("a", "1").SetURLQuery("a", "1").SetProxy("http://127.0.0.1:8118")
+	baseTransport := base.Client.Transport.(*http.Transport)
+
+	x := base.SetHeader("b", "2").AddHeader("a", "3").SetURLQuery("b", "2").AddURLQuery("a", "3")
+	y := base.DeleteHeaders("a").DeleteProxy().UseDNS("127.0.0.1")
+
+	if !reflect.DeepEqual(base.Headers, http.Header{"A": {"1"}}) {
+		t.Errorf("Headers 被派生链修改: %v", base.Headers)
+	}
+	if !reflect.DeepEqual(base.URLQuery, url.Values{"a": {"1"}}) {
+		t.Errorf("URLQuery 被派生链修改: %v", base.URLQuery)
+	}
+	if !reflect.DeepEqual(x.Headers, http.Header{"A": {"1", "3"}, "B": {"2"}}) || !reflect.DeepEqual(x.URLQuery, url.Values{"a": {"1", "3"}, "b": {"2"}}) {
+		t.Error("派生链设置失败")
+	}
+
+	if base.Client.Transport != baseTransport || baseTransport.Proxy == nil || baseTransport.DialContext != nil {
+		t.Error("Transport 被派生链修改")
+	}
+	if tr := y.Client.Transport.(*http.Transport); tr.Proxy != nil || tr.DialContext == nil {
+		t.Error("派生链 Transport 设置失败")
+	}
+
+	values := []string{"1"}
+	z := SetURLQuery("a", values...)
+	values[0] = "2"
+	if z.URLQuery.Get("a") != "1" {
+		t.Error("URLQuery 与调用方共享切片")
+	}
+}
+
+func TestConcurrentChain(t *testing.T) { // 共享的基础客户端可以在多个 goroutine 中派生
+	base := SetHeader("a", "1").SetURLQuery("a", "1").Use(func(next Handler) Handler { return next })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v := strconv.Itoa(i)
+			c := base.SetHeader("b", v).AddHeader("a", v).AddURLQuery("a", v).Use(func(next Handler) Handler { return next })
+			if c.Headers.Get("B") != v || len(c.URLQuery["a"]) != 2 || len(c.Middlewares) != 2 {
+				t.Error("并发派生结果不一致")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(base.Headers) != 1 || len(base.URLQuery["a"]) != 1 || len(base.Middlewares) != 1 {
+		t.Error("并发派生修改了基础客户端")
+	}
 }
 
 func TestSetBaseURL(t *testing.T) {
@@ -75,7 +150,7 @@ func TestURLQuery(t *testing.T) {
 }
 
 func TestSetHeader(t *testing.T) {
-	if SetContentType(MIMEApplicationJSON).Headers[HeaderContentType] != MIMEApplicationJSON {
+	if SetContentType(MIMEApplicationJSON).Headers.Get(HeaderContentType) != MIMEApplicationJSON {
 		t.Error("设置 Content-Type 失败")
 	}
 }
@@ -92,3 +167,124 @@ func TestSetBody(t *testing.T) {
 		t.Error("内部保存 Body 与原始值不一致")
 	}
 }
+
+func TestSetBodyEncoder(t *testing.T) {
+	m := struct {
+		XMLName struct{} `xml:"user" json:"-"`
+		Name    string   `xml:"name" json:"name"`
+	}{Name: "niconiconi"}
+
+	b, _ := ioutil.ReadAll(SetContentType(MIMEApplicationXML).SetBody(m).Body)
+	if string(b) != "<user><name>niconiconi</name></user>" {
+		t.Errorf("xml 编码错误: %s", b)
+	}
+
+	client := SetBody(url.Values{"name": {"niconiconi"}})
+	b, _ = ioutil.ReadAll(client.Body)
+	if string(b) != "name=niconiconi" || client.Headers.Get(HeaderContentType) != MIMEXWWWFormURLEncoded {
+		t.Errorf("表单编码错误: %s", b)
+	}
+
+	// 编码器是全局注册的 测试结束后恢复原状 避免影响其它测试
+	codecMu.RLock()
+	prev, registered := encoders[MIMEApplicationMsgpack]
+	codecMu.RUnlock()
+	t.Cleanup(func() {
+		codecMu.Lock()
+		defer codecMu.Unlock()
+		if registered {
+			encoders[MIMEApplicationMsgpack] = prev
+		} else {
+			delete(encoders, MIMEApplicationMsgpack)
+		}
+	})
+
+	RegisterEncoder(MIMEApplicationMsgpack, func(v interface{}) ([]byte, error) {
+		return []byte("msgpack"), nil
+	})
+	b, _ = ioutil.ReadAll(SetContentType(MIMEApplicationMsgpack).SetBody(m).Body)
+	if string(b) != "msgpack" {
+		t.Error("自定义编码器未生效")
+	}
+}
+
+func TestAddHeader(t *testing.T) {
+	client := SetHeader("content-type", MIMETextPlain).SetHeader(HeaderContentType, MIMEApplicationJSON)
+	if !reflect.DeepEqual(client.Headers, http.Header{HeaderContentType: {MIMEApplicationJSON}}) {
+		t.Errorf("header 键名未规范化: %v", client.Headers)
+	}
+
+	client = client.AddHeader("x-forwarded-for", "127.0.0.1").AddHeader("X-Forwarded-For", "127.0.0.2")
+	if !reflect.DeepEqual(client.Headers.Values("X-Forwarded-For"), []string{"127.0.0.1", "127.0.0.2"}) {
+		t.Error("添加多值 header 失败")
+	}
+
+	if client.DeleteHeaders("x-forwarded-for").Headers.Get("X-Forwarded-For") != "" {
+		t.Error("删除 header 失败")
+	}
+
+	req, err := NewRequestWithContext(context.Background(), http.MethodGet, "https://example.com", client.Headers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Header.Values("X-Forwarded-For")) != 2 || len(req.Header.Values(HeaderContentType)) != 1 {
+		t.Errorf("请求 header 不一致: %v", req.Header)
+	}
+
+	t.Setenv("UserAgent", "niconiconi")
+	req, err = NewRequestWithContext(context.Background(), http.MethodGet, "https://example.com", http.Header{"User-Agent": {"", "elissa"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(req.Header.Values("User-Agent"), []string{"elissa"}) {
+		t.Errorf("header 应覆盖环境变量设置的值: %v", req.Header)
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	cases := []struct {
+		base     string
+		preserve bool
+		url      string
+		want     string
+	}{
+		{"https://example.com/v1/", true, "/users", "https://example.com/v1/users"},
+		{"https://example.com/v1", true, "/users", "https://example.com/v1/users"},
+		{"https://example.com/v1", true, "users/1", "https://example.com/v1/users/1"},
+		{"https://example.com/v1/", true, "../v2/users", "https://example.com/v2/users"},
+		{"https://example.com/v1", true, "?page=1", "https://example.com/v1?page=1"},
+		{"https://example.com/v1", true, "", "https://example.com/v1"},
+		{"https://example.com", false, "/get", "https://example.com/get"},
+		{"https://example.com/v1/", false, "/users", "https://example.com/users"},
+		{"https://example.com/v1/", false, "users", "https://example.com/v1/users"},
+		{"https://example.com/v1", false, "users", "https://example.com/users"},
+		{"https://example.com/v1/", false, "https://example.org/get", "https://example.org/get"},
+		{"https://example.com/v1/", false, "//example.org/get", "https://example.org/get"},
+	}
+
+	for _, v := range cases {
+		got, err := SetBaseURL(v.base).SetPreserveBasePath(v.preserve).buildURL(v.url)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got != v.want {
+			t.Errorf("%s + %s = %s 期望 %s", v.base, v.url, got, v.want)
+		}
+	}
+
+	if SetBaseURL("/v1").Error == nil {
+		t.Error("基础 URL 缺少协议与主机时应返回错误")
+	}
+	if c := SetBaseURL("https://example.com").SetBaseURL(""); c.Error != nil || c.BaseURL != "" {
+		t.Error("空的基础 URL 应等同于 DeleteBaseURL")
+	}
+	if c := SetBaseURL("{scheme}://example.com/{version}"); c.Error != nil {
+		t.Error("包含模板表达式的基础 URL 应在发送时校验", c.Error)
+	}
+	if _, err := Get("/v1"); err == nil {
+		t.Error("相对地址缺少基础 URL 时应返回错误")
+	} else if _, ok := err.(*LinkError); !ok {
+		t.Error("地址错误应通过 LinkError 返回")
+	}
+}
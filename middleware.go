@@ -0,0 +1,89 @@
+package httpc
+
+import (
+	"net/http"
+)
+
+// Handler 发送请求的处理函数
+type Handler func(req *http.Request) (*Response, error)
+
+// Middleware 中间件 在 next 调用前后可以对请求以及响应进行加工
+type Middleware func(next Handler) Handler
+
+// Use 添加中间件（先添加的中间件在外层）
+func (c Client) Use(middlewares ...Middleware) *Client {
+	// 复制一份，防止与其它链共用底层数组
+	c.Middlewares = append(append([]Middleware(nil), c.Middlewares...), middlewares...)
+	return &c
+}
+
+// OnRequest 添加请求拦截器（请求发送前调用 返回错误则终止请求）
+func (c Client) OnRequest(fn func(req *http.Request) error) *Client {
+	return c.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*Response, error) {
+			if err := fn(req); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	})
+}
+
+// OnResponse 添加响应拦截器（请求成功后调用 返回错误则作为请求的错误）
+func (c Client) OnResponse(fn func(resp *Response) error) *Client {
+	return c.Use(func(next Handler) Handler {
+		return func(req *http.Request) (*Response, error) {
+			resp, err := next(req)
+			if err != nil {
+				return resp, err
+			}
+			return resp, fn(resp)
+		}
+	})
+}
+
+// DeleteMiddlewares 删除所有中间件
+func (c Client) DeleteMiddlewares() *Client {
+	c.Middlewares = nil
+	return &c
+}
+
+// handler 组装中间件 （认证在中间件内层执行 签名在最内层执行）
+func (c Client) handler() Handler {
+	h := Handler(c.send)
+	if c.Signer != nil {
+		h = signHandler(c.Signer, h)
+	}
+	if c.Auth != nil {
+		h = c.Auth.Authenticate(h)
+	}
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		h = c.Middlewares[i](h)
+	}
+
+	return h
+}
+
+// send 使用 http.Client 发送请求（会自动声明并解压 gzip deflate br zstd 编码的响应）
+func (c Client) send(req *http.Request) (*Response, error) {
+	// 复制一份 不修改调用方的请求
+	decompress := c.shouldDecompress(req)
+	if decompress || c.UploadProgress != nil {
+		shallow := *req
+		shallow.Header = req.Header.Clone()
+		req = &shallow
+	}
+	if decompress {
+		req.Header.Set(HeaderAcceptEncoding, acceptEncoding)
+	}
+	if c.UploadProgress != nil {
+		wrapUploadProgress(req, c.UploadProgress)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err == nil && decompress {
+		decompressBody(resp)
+	}
+
+	return &Response{Response: resp}, err
+}
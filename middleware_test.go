@@ -0,0 +1,44 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestUse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *http.Request) (*Response, error) {
+				order = append(order, name+" before")
+				resp, err := next(req)
+				order = append(order, name+" after")
+				return resp, err
+			}
+		}
+	}
+
+	base := Use(trace("a"))
+	_ = base.Use(trace("c")) // 派生的中间件不应影响 base 以及其它派生链
+	res, err := base.Use(trace("b")).OnRequest(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer niconiconi")
+		return nil
+	}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Text() != "Bearer niconiconi" {
+		t.Error("请求拦截器未生效")
+	}
+	if !reflect.DeepEqual(order, []string{"a before", "b before", "b after", "a after"}) {
+		t.Errorf("中间件执行顺序错误: %v", order)
+	}
+}
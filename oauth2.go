@@ -0,0 +1,246 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryDelta token 提前过期的时间（防止发送途中过期）
+const oauth2ExpiryDelta = 10 * time.Second
+
+// OAuth2Config OAuth2 配置
+type OAuth2Config struct {
+	TokenURL     string     // 获取 token 的地址
+	ClientID     string     // 客户端 ID
+	ClientSecret string     // 客户端密钥
+	Scopes       []string   // 权限范围
+	RefreshToken string     // 不为空时使用 refresh_token 授权 否则使用 client_credentials 授权
+	Params       url.Values // 额外参数 （如 audience）
+	AuthInParams bool       // 客户端凭证放在参数中 （默认使用 Basic 认证）
+	Client       *Client    // 获取 token 使用的客户端 （默认为 DefaultClient）
+}
+
+// OAuth2Token OAuth2 token
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    int64     `json:"expires_in"`
+	Expiry       time.Time `json:"-"` // 过期时间 零值为不过期
+}
+
+// valid token 是否可用
+func (t *OAuth2Token) valid() bool {
+	return t != nil && t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Add(oauth2ExpiryDelta).Before(t.Expiry))
+}
+
+// OAuth2Error token 接口返回的错误
+type OAuth2Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	URI         string `json:"error_uri"`
+}
+
+func (e OAuth2Error) Error() string {
+	if e.Description == "" {
+		return "oauth2: " + e.Code
+	}
+	return fmt.Sprintf("oauth2: %s: %s", e.Code, e.Description)
+}
+
+// OAuth2 OAuth2 认证（实现 Authenticator）
+// token 会缓存到过期为止，并发请求只会获取一次 token，收到 401 时会刷新 token 并重试一次
+type OAuth2 struct {
+	config OAuth2Config
+
+	mu           sync.Mutex
+	token        *OAuth2Token
+	refreshToken string       // 最新的 refresh_token （服务端轮换后会更新）
+	flight       *oauth2Fetch // 正在进行的获取
+}
+
+// oauth2Fetch 一次正在进行的 token 获取
+type oauth2Fetch struct {
+	done  chan struct{}
+	token *OAuth2Token
+	err   error
+}
+
+// NewOAuth2 新建 OAuth2 认证 请在多次请求之间复用以便缓存 token
+func NewOAuth2(config OAuth2Config) *OAuth2 {
+	config.Scopes = append([]string(nil), config.Scopes...)
+	config.Params = cloneValues(config.Params)
+	return &OAuth2{config: config, refreshToken: config.RefreshToken}
+}
+
+// SetOAuth2 使用 OAuth2 认证
+func (c Client) SetOAuth2(auth *OAuth2) *Client {
+	return c.SetAuth(auth)
+}
+
+// Token 获取 token （未过期时使用缓存）
+// 获取 token 时不持有锁，其它协程会等待同一次获取的结果（等待可以通过各自的 ctx 取消）
+func (o *OAuth2) Token(ctx context.Context) (*OAuth2Token, error) {
+	for {
+		o.mu.Lock()
+		if o.token.valid() {
+			token := o.token
+			o.mu.Unlock()
+			return token, nil
+		}
+
+		flight := o.flight
+		if flight == nil {
+			flight = &oauth2Fetch{done: make(chan struct{})}
+			o.flight = flight
+			refreshToken := o.refreshToken
+			o.mu.Unlock()
+
+			flight.token, flight.err = o.fetch(ctx, refreshToken)
+
+			o.mu.Lock()
+			if flight.err == nil {
+				o.token = flight.token
+				o.refreshToken = flight.token.RefreshToken
+			}
+			o.flight = nil
+			o.mu.Unlock()
+			close(flight.done)
+
+			return flight.token, flight.err
+		}
+		o.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-flight.done:
+		}
+		if flight.err == nil {
+			return flight.token, nil
+		}
+		// 发起获取的一方被取消时 由当前协程重新获取
+		if !errors.Is(flight.err, context.Canceled) && !errors.Is(flight.err, context.DeadlineExceeded) {
+			return nil, flight.err
+		}
+	}
+}
+
+// invalidate 使 access_token 失效（仅当其仍为当前 token 时 防止并发时重复刷新）
+// 最新的 refresh_token 会保留 下次获取时继续使用
+func (o *OAuth2) invalidate(token *OAuth2Token) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token == token {
+		o.token = nil
+	}
+}
+
+// fetch 从 token 接口获取 token refreshToken 为空时使用 client_credentials 授权
+func (o *OAuth2) fetch(ctx context.Context, refreshToken string) (*OAuth2Token, error) {
+	values := cloneValues(o.config.Params)
+	if values == nil {
+		values = url.Values{}
+	}
+
+	if refreshToken != "" {
+		values.Set("grant_type", "refresh_token")
+		values.Set("refresh_token", refreshToken)
+	} else {
+		values.Set("grant_type", "client_credentials")
+	}
+	if len(o.config.Scopes) != 0 {
+		values.Set("scope", strings.Join(o.config.Scopes, " "))
+	}
+
+	client := o.config.Client
+	if client == nil {
+		client = DefaultClient
+	}
+	if o.config.AuthInParams {
+		values.Set("client_id", o.config.ClientID)
+		if o.config.ClientSecret != "" {
+			values.Set("client_secret", o.config.ClientSecret)
+		}
+	} else {
+		client = client.SetBasicAuth(url.QueryEscape(o.config.ClientID), url.QueryEscape(o.config.ClientSecret))
+	}
+
+	var token OAuth2Token
+	var oauthErr OAuth2Error
+	res, err := client.SetAccept(MIMEApplicationJSON).SetBody(values).SetResult(&token).SetErrorResult(&oauthErr).PostWithContext(ctx, o.config.TokenURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if !res.IsSuccessful() {
+		if oauthErr.Code != "" {
+			return nil, oauthErr
+		}
+		return nil, statusError(res)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("oauth2: server response missing access_token")
+	}
+
+	if token.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	if token.RefreshToken == "" { // 未返回新的 refresh_token 时继续使用旧的
+		token.RefreshToken = refreshToken
+	}
+
+	return &token, nil
+}
+
+func (o *OAuth2) replayBody() bool {
+	return true
+}
+
+// Authenticate 实现 Authenticator
+func (o *OAuth2) Authenticate(next Handler) Handler {
+	return func(req *http.Request) (*Response, error) {
+		token, err := o.Token(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		setTokenHeader(req, token)
+
+		resp, err := next(req)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+
+		retry, ok := cloneRequest(req)
+		if !ok {
+			return resp, nil // body 无法重复读取 交由调用方处理
+		}
+		discardBody(resp.Body)
+
+		o.invalidate(token)
+		if token, err = o.Token(req.Context()); err != nil {
+			return nil, err
+		}
+		setTokenHeader(retry, token)
+
+		return next(retry)
+	}
+}
+
+// setTokenHeader 设置 token
+func setTokenHeader(req *http.Request, token *OAuth2Token) {
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+
+	req.Header.Set(HeaderAuthorization, tokenType+" "+token.AccessToken)
+}
@@ -0,0 +1,122 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOAuth2(t *testing.T) {
+	var issued int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "niconiconi" || secret != "123456" {
+			w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" {
+			t.Errorf("授权参数错误: %v", r.Form)
+		}
+
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		w.Write([]byte(`{"access_token":"token` + strconv.Itoa(int(n)) + `","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	var rejected int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一个 token 在首次使用后被吊销
+		if r.Header.Get(HeaderAuthorization) == "Bearer token1" && r.URL.Path == "/revoke" && atomic.AddInt32(&rejected, 1) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.Header.Get(HeaderAuthorization)))
+	}))
+	defer srv.Close()
+
+	client := SetOAuth2(NewOAuth2(OAuth2Config{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "niconiconi",
+		ClientSecret: "123456",
+		Scopes:       []string{"read", "write"},
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if text := res.Text(); text != "Bearer token1" {
+				t.Errorf("token 错误: %q", text)
+			}
+		}()
+	}
+	wg.Wait()
+	if issued != 1 {
+		t.Errorf("并发请求应只获取一次 token: %d", issued)
+	}
+
+	res, err := client.Get(srv.URL + "/revoke")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Text(); text != "Bearer token2" || issued != 2 {
+		t.Errorf("401 后应刷新 token 并重试: %q", text)
+	}
+
+	_, err = SetOAuth2(NewOAuth2(OAuth2Config{TokenURL: tokenServer.URL, ClientID: "elissa"})).Get(srv.URL)
+	if oauthErr, ok := err.(OAuth2Error); !ok || oauthErr.Code != "invalid_client" {
+		t.Errorf("应返回 OAuth2Error: %v", err)
+	}
+}
+
+func TestOAuth2RefreshRotation(t *testing.T) {
+	var mu sync.Mutex
+	valid, issued := "refresh0", 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != valid {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		// 每个 refresh_token 只能使用一次
+		issued++
+		valid = "refresh" + strconv.Itoa(issued)
+		w.Write([]byte(`{"access_token":"token` + strconv.Itoa(issued) + `","refresh_token":"` + valid + `","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderAuthorization) == "Bearer token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.Header.Get(HeaderAuthorization)))
+	}))
+	defer srv.Close()
+
+	client := SetOAuth2(NewOAuth2(OAuth2Config{TokenURL: tokenServer.URL, ClientID: "niconiconi", RefreshToken: "refresh0"}))
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Text(); text != "Bearer token2" {
+		t.Errorf("401 后应使用轮换后的 refresh_token 刷新: %q", text)
+	}
+}
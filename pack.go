@@ -3,6 +3,7 @@ package httpc
 import (
 	"context"
 	"net/http"
+	stdURL "net/url"
 	"time"
 )
 
@@ -14,6 +15,21 @@ func UseDNS(dns ...string) *Client {
 	return DefaultClient.UseDNS(dns...)
 }
 
+// UseResolver 使用指定解析器解析域名
+func UseResolver(r *Resolver) *Client {
+	return DefaultClient.UseResolver(r)
+}
+
+// SetAddressFamily 设置连接使用的地址族
+func SetAddressFamily(family AddressFamily) *Client {
+	return DefaultClient.SetAddressFamily(family)
+}
+
+// SetHostOverrides 设置域名对应的地址
+func SetHostOverrides(overrides map[string]string) *Client {
+	return DefaultClient.SetHostOverrides(overrides)
+}
+
 // SetProxy 设置代理（支持 http 以及 socks5 代理）
 func SetProxy(proxy string) *Client {
 	return DefaultClient.SetProxy(proxy)
@@ -24,11 +40,46 @@ func SetTimeout(duration time.Duration) *Client {
 	return DefaultClient.SetTimeout(duration)
 }
 
+// SetRetry 设置重试策略
+func SetRetry(policy RetryPolicy) *Client {
+	return DefaultClient.SetRetry(policy)
+}
+
+// Use 添加中间件
+func Use(middlewares ...Middleware) *Client {
+	return DefaultClient.Use(middlewares...)
+}
+
+// OnRequest 添加请求拦截器
+func OnRequest(fn func(req *http.Request) error) *Client {
+	return DefaultClient.OnRequest(fn)
+}
+
+// OnResponse 添加响应拦截器
+func OnResponse(fn func(resp *Response) error) *Client {
+	return DefaultClient.OnResponse(fn)
+}
+
 // SetBaseURL 设置基础 URL 后续访问将默认拼接本URL
 func SetBaseURL(url string) *Client {
 	return DefaultClient.SetBaseURL(url)
 }
 
+// SetPreserveBasePath 解析相对地址时保留基础 URL 的路径
+func SetPreserveBasePath(preserve bool) *Client {
+	return DefaultClient.SetPreserveBasePath(preserve)
+}
+
+// SetPathParam 设置路径参数
+func SetPathParam(key, value string) *Client {
+	return DefaultClient.SetPathParam(key, value)
+}
+
+// SetPathParams 设置多个路径参数
+func SetPathParams(params map[string]string) *Client {
+	return DefaultClient.SetPathParams(params)
+}
+
 // AddURLQuery 添加 URL 查询参数
 func AddURLQuery(key string, values ...string) *Client {
 	return DefaultClient.AddURLQuery(key, values...)
@@ -44,6 +95,11 @@ func SetURLQuery(key string, values ...string) *Client {
 	return DefaultClient.SetURLQuery(key, values...)
 }
 
+// SetURLQueryStruct 将 struct 编码为 URL 查询参数
+func SetURLQueryStruct(v interface{}) *Client {
+	return DefaultClient.SetURLQueryStruct(v)
+}
+
 // SetURLQueryS 以 string 的方式设置查询参数
 func SetURLQueryS(query string) *Client {
 	return DefaultClient.SetURLQueryS(query)
@@ -54,11 +110,56 @@ func SetHeader(key, value string) *Client {
 	return DefaultClient.SetHeader(key, value)
 }
 
+// AddHeader 添加 header
+func AddHeader(key string, values ...string) *Client {
+	return DefaultClient.AddHeader(key, values...)
+}
+
 // SetHeaders 设置 headers
 func SetHeaders(headers map[string]string) *Client {
 	return DefaultClient.SetHeaders(headers)
 }
 
+// SetCookieJar 设置 cookie jar
+func SetCookieJar(jar http.CookieJar) *Client {
+	return DefaultClient.SetCookieJar(jar)
+}
+
+// SetCookies 设置请求时携带的 cookie
+func SetCookies(cookies ...*http.Cookie) *Client {
+	return DefaultClient.SetCookies(cookies...)
+}
+
+// SetAuth 设置认证方式
+func SetAuth(auth Authenticator) *Client {
+	return DefaultClient.SetAuth(auth)
+}
+
+// SetBasicAuth 使用 Basic 认证
+func SetBasicAuth(username, password string) *Client {
+	return DefaultClient.SetBasicAuth(username, password)
+}
+
+// SetBearerToken 使用 Bearer 认证
+func SetBearerToken(token string) *Client {
+	return DefaultClient.SetBearerToken(token)
+}
+
+// SetDigestAuth 使用 Digest 认证
+func SetDigestAuth(username, password string) *Client {
+	return DefaultClient.SetDigestAuth(username, password)
+}
+
+// SetOAuth2 使用 OAuth2 认证
+func SetOAuth2(auth *OAuth2) *Client {
+	return DefaultClient.SetOAuth2(auth)
+}
+
+// SetSigner 设置请求签名
+func SetSigner(signer Signer) *Client {
+	return DefaultClient.SetSigner(signer)
+}
+
 // SetUserAgent 设置浏览器标识
 func SetUserAgent(ua string) *Client {
 	return DefaultClient.SetUserAgent(ua)
@@ -69,17 +170,77 @@ func SetContentType(contentType string) *Client {
 	return DefaultClient.SetContentType(contentType)
 }
 
+// SetAccept 设置可接受的内容类型
+func SetAccept(mediaTypes ...string) *Client {
+	return DefaultClient.SetAccept(mediaTypes...)
+}
+
 // SetBody 设置 body 内容
 func SetBody(in interface{}) *Client {
 	return DefaultClient.SetBody(in)
 }
 
+// SetBodyCompression 设置请求 body 的压缩方式
+func SetBodyCompression(encoding string) *Client {
+	return DefaultClient.SetBodyCompression(encoding)
+}
+
 // SetFromData 设置表单数据
 func SetFromData(rows ...FromDataRow) *Client {
 	return DefaultClient.SetFromData(rows...)
 }
 
-// Do 发送自定义请求
+// SetFromDataStream 以流的方式设置表单数据
+func SetFromDataStream(rows ...FromDataRow) *Client {
+	return DefaultClient.SetFromDataStream(rows...)
+}
+
+// SetFromDataStruct 以 struct 设置表单数据
+func SetFromDataStruct(v interface{}) *Client {
+	return DefaultClient.SetFromDataStruct(v)
+}
+
+// SetFromDataStructStream 以 struct 设置表单数据 并以流的方式发送
+func SetFromDataStructStream(v interface{}) *Client {
+	return DefaultClient.SetFromDataStructStream(v)
+}
+
+// SetForm 设置简单表单
+func SetForm(v interface{}) *Client {
+	return DefaultClient.SetForm(v)
+}
+
+// SetUploadProgress 设置上传进度回调
+func SetUploadProgress(fn ProgressFunc) *Client {
+	return DefaultClient.SetUploadProgress(fn)
+}
+
+// SetDownloadProgress 设置下载进度回调
+func SetDownloadProgress(fn ProgressFunc) *Client {
+	return DefaultClient.SetDownloadProgress(fn)
+}
+
+// Download 下载文件到指定路径 支持断点续传
+func Download(ctx context.Context, url string, path string) error {
+	return DefaultClient.Download(ctx, url, path)
+}
+
+// ErrorOnStatus 非 2xx 响应时返回 HTTPError
+func ErrorOnStatus() *Client {
+	return DefaultClient.ErrorOnStatus()
+}
+
+// SetResult 请求成功时自动解析响应
+func SetResult(ptr interface{}) *Client {
+	return DefaultClient.SetResult(ptr)
+}
+
+// SetErrorResult 请求失败时自动解析响应
+func SetErrorResult(ptr interface{}) *Client {
+	return DefaultClient.SetErrorResult(ptr)
+}
+
+// Do 发送自定义请求（会经过中间件）
 func Do(req *http.Request) (*Response, error) {
 	return DefaultClient.Do(req)
 }
@@ -129,6 +290,11 @@ func PostWithContext(ctx context.Context, url string) (*Response, error) {
 	return DefaultClient.PostWithContext(ctx, url)
 }
 
+// PostForm 以简单表单的方式发送 POST 请求
+func PostForm(url string, values stdURL.Values) (*Response, error) {
+	return DefaultClient.PostForm(url, values)
+}
+
 // Put 发送 PUT 请求
 func Put(url string) (*Response, error) {
 	return DefaultClient.Put(url)
@@ -0,0 +1,91 @@
+package httpc
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// progressInterval 进度回调的最小间隔（防止每次写入都触发回调）
+const progressInterval = 100 * time.Millisecond
+
+// ProgressFunc 进度回调 total 为 -1 时表示总长度未知
+type ProgressFunc func(current, total int64)
+
+// SetUploadProgress 设置上传进度回调
+func (c Client) SetUploadProgress(fn ProgressFunc) *Client {
+	c.UploadProgress = fn
+	return &c
+}
+
+// SetDownloadProgress 设置下载进度回调（读取响应 body 时触发）
+func (c Client) SetDownloadProgress(fn ProgressFunc) *Client {
+	c.DownloadProgress = fn
+	return &c
+}
+
+// SetDownloadProgress 设置下载进度回调（读取 body 时触发）
+func (r *Response) SetDownloadProgress(fn ProgressFunc) *Response {
+	if r.Response == nil || r.Body == nil || fn == nil {
+		return r
+	}
+
+	r.Body = newProgressReader(r.Body, r.ContentLength, fn)
+	return r
+}
+
+// wrapUploadProgress 为请求 body 添加进度回调
+func wrapUploadProgress(req *http.Request, fn ProgressFunc) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	total := req.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+
+	req.Body = newProgressReader(req.Body, total, fn)
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return newProgressReader(body, total, fn), nil
+		}
+	}
+}
+
+// progressReader 带进度回调的读取器
+type progressReader struct {
+	io.ReadCloser
+
+	fn      ProgressFunc
+	total   int64
+	current int64
+	last    time.Time
+	done    bool
+}
+
+func newProgressReader(r io.ReadCloser, total int64, fn ProgressFunc) *progressReader {
+	return &progressReader{ReadCloser: r, fn: fn, total: total}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	p.current += int64(n)
+
+	if p.done {
+		return n, err
+	}
+
+	finished := err == io.EOF || (p.total >= 0 && p.current >= p.total)
+	if finished || (n > 0 && time.Since(p.last) >= progressInterval) {
+		p.done = finished
+		p.last = time.Now()
+		p.fn(p.current, p.total)
+	}
+
+	return n, err
+}
@@ -0,0 +1,40 @@
+package httpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	data := strings.Repeat("niconiconi", 1024)
+	var upload, download [2]int64
+	res, err := SetBody(data).SetUploadProgress(func(current, total int64) {
+		upload = [2]int64{current, total}
+	}).SetDownloadProgress(func(current, total int64) {
+		download = [2]int64{current, total}
+	}).Post(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text(len(data)) != data {
+		t.Error("响应内容不一致")
+	}
+
+	if upload != [2]int64{int64(len(data)), int64(len(data))} {
+		t.Errorf("上传进度错误: %v", upload)
+	}
+	if download != [2]int64{int64(len(data)), int64(len(data))} {
+		t.Errorf("下载进度错误: %v", download)
+	}
+}
@@ -0,0 +1,266 @@
+package httpc
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	stdURL "net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryEncoder 自定义查询参数编码 实现该接口的字段会调用本方法而不是默认编码
+type QueryEncoder interface {
+	EncodeValues(key string, values *stdURL.Values) error
+}
+
+var (
+	queryEncoderType  = reflect.TypeOf((*QueryEncoder)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+// SetURLQueryStruct 将 struct 编码为 URL 查询参数（覆盖已有的同名参数）
+//
+// 使用 url 标签指定参数名以及选项 如 `url:"name,omitempty"`，`url:"-"` 为忽略该字段
+//
+//	omitempty 零值时忽略
+//	comma     切片以逗号拼接 （默认为重复的键）
+//	brackets  切片的键名添加 []
+//	int       bool 编码为 1 和 0
+//	unix      time.Time 编码为秒级时间戳 （也可以通过 layout 标签指定格式 默认为 RFC 3339）
+//	unixmilli time.Time 编码为毫秒级时间戳
+//
+// 匿名嵌入的 struct 会展开到同一层，其它 struct 字段的键名为 parent[child]
+func (c Client) SetURLQueryStruct(v interface{}) *Client {
+	values := stdURL.Values{}
+	if err := encodeQueryStruct(reflect.ValueOf(v), "url", "", values); err != nil {
+		return c.handleError(err)
+	}
+
+	c.URLQuery = cloneValues(c.URLQuery)
+	if c.URLQuery == nil {
+		c.URLQuery = stdURL.Values{}
+	}
+	for k, v := range values {
+		c.URLQuery[k] = v
+	}
+
+	return &c
+}
+
+// encodeQueryStruct 编码 struct 的所有字段 tagKey 为使用的标签名 scope 为上层的键名
+func encodeQueryStruct(value reflect.Value, tagKey string, scope string, values stdURL.Values) error {
+	return walkStruct(value, tagKey, scope, func(field reflect.StructField, fv reflect.Value, name string, opts tagOptions) error {
+		if opts.has("omitempty") && isEmptyValue(fv) {
+			return nil
+		}
+		return encodeQueryValue(fv, tagKey, name, opts, field.Tag.Get("layout"), values)
+	})
+}
+
+// walkStruct 遍历 struct 的导出字段 匿名嵌入的 struct 会展开到同一层
+func walkStruct(value reflect.Value, tagKey string, scope string, fn func(field reflect.StructField, fv reflect.Value, name string, opts tagOptions) error) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("%s: expects struct input, got %v", tagKey, value.Kind())
+	}
+
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous { // 未导出
+			continue
+		}
+
+		tag := field.Tag.Get(tagKey)
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+
+		fv := value.Field(i)
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !implementsQueryValue(ft) {
+				if err := walkStruct(fv, tagKey, scope, fn); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if scope != "" {
+			name = scope + "[" + name + "]"
+		}
+
+		if err := fn(field, fv, name, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeQueryValue 编码单个字段
+func encodeQueryValue(fv reflect.Value, tagKey string, name string, opts tagOptions, layout string, values stdURL.Values) error {
+	if fv.Type().Implements(queryEncoderType) {
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			return nil
+		}
+		return fv.Interface().(QueryEncoder).EncodeValues(name, &values)
+	}
+	if fv.CanAddr() && fv.Addr().Type().Implements(queryEncoderType) {
+		return fv.Addr().Interface().(QueryEncoder).EncodeValues(name, &values)
+	}
+
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			values.Add(name, "")
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	switch {
+	case fv.Type() == timeType:
+		values.Add(name, formatTime(fv.Interface().(time.Time), opts, layout))
+		return nil
+	case fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array:
+		if fv.Type().Elem().Kind() == reflect.Uint8 && fv.Kind() == reflect.Slice { // []byte
+			values.Add(name, string(fv.Bytes()))
+			return nil
+		}
+
+		if opts.has("brackets") {
+			name += "[]"
+		}
+		// 元素为 struct 时会产生 name[child] 等多个键 需要全部保留
+		items := stdURL.Values{}
+		for i := 0; i < fv.Len(); i++ {
+			if err := encodeQueryValue(fv.Index(i), tagKey, name, opts, layout, items); err != nil {
+				return err
+			}
+		}
+		for k, v := range items {
+			if opts.has("comma") {
+				values.Add(k, strings.Join(v, ","))
+				continue
+			}
+			values[k] = append(values[k], v...)
+		}
+		return nil
+	case fv.Kind() == reflect.Struct && !implementsQueryValue(fv.Type()):
+		return encodeQueryStruct(fv, tagKey, name, values)
+	}
+
+	s, err := formatQueryValue(fv, opts)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", tagKey, name, err)
+	}
+	values.Add(name, s)
+
+	return nil
+}
+
+// formatQueryValue 将基础类型格式化为字符串
+func formatQueryValue(fv reflect.Value, opts tagOptions) (string, error) {
+	if fv.Type().Implements(textMarshalerType) {
+		b, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		if opts.has("int") {
+			if fv.Bool() {
+				return "1", nil
+			}
+			return "0", nil
+		}
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 64), nil
+	case reflect.Interface:
+		if fv.IsNil() {
+			return "", nil
+		}
+		return formatQueryValue(fv.Elem(), opts)
+	}
+
+	return "", errors.New("unsupported type " + fv.Type().String())
+}
+
+// formatTime 格式化时间
+func formatTime(t time.Time, opts tagOptions, layout string) string {
+	switch {
+	case opts.has("unix"):
+		return strconv.FormatInt(t.Unix(), 10)
+	case opts.has("unixmilli"):
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case layout != "":
+		return t.Format(layout)
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// implementsQueryValue 类型自行处理编码（不展开为字段）
+func implementsQueryValue(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return t == timeType || t.Implements(queryEncoderType) || pt.Implements(queryEncoderType) || t.Implements(textMarshalerType)
+}
+
+// isEmptyValue 是否为零值
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+
+	return v.IsZero()
+}
+
+// tagOptions 标签选项
+type tagOptions []string
+
+func (o tagOptions) has(option string) bool {
+	for _, v := range o {
+		if v == option {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTag 解析标签 name,opt1,opt2
+func parseTag(tag string) (string, tagOptions) {
+	sp := strings.Split(tag, ",")
+	return sp[0], tagOptions(sp[1:])
+}
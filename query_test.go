@@ -0,0 +1,98 @@
+package httpc
+
+import (
+	stdURL "net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type queryPage struct {
+	Page int `url:"page,omitempty"`
+	Size int `url:"size"`
+}
+
+type querySort string
+
+func (s querySort) EncodeValues(key string, values *stdURL.Values) error {
+	values.Set(key, strings.ToUpper(string(s)))
+	return nil
+}
+
+type queryFilter struct {
+	Status string `url:"status"`
+}
+
+type queryOptions struct {
+	queryPage
+	Name    *string     `url:"name,omitempty"`
+	Tags    []string    `url:"tag"`
+	IDs     []int       `url:"id,comma"`
+	Keys    []string    `url:"key,brackets"`
+	Active  bool        `url:"active,int"`
+	Since   time.Time   `url:"since" layout:"2006-01-02"`
+	Until   time.Time   `url:"until,unix"`
+	Sort    querySort   `url:"sort"`
+	Filter  queryFilter `url:"filter"`
+	Ignored string      `url:"-"`
+	private string
+}
+
+func TestSetURLQueryStruct(t *testing.T) {
+	name := "foo"
+	opts := &queryOptions{
+		queryPage: queryPage{Size: 20},
+		Name:      &name,
+		Tags:      []string{"a", "b"},
+		IDs:       []int{1, 2, 3},
+		Keys:      []string{"x"},
+		Active:    true,
+		Since:     time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		Until:     time.Unix(1600000000, 0),
+		Sort:      "desc",
+		Filter:    queryFilter{Status: "open"},
+		Ignored:   "ignored",
+		private:   "private",
+	}
+
+	c := SetURLQuery("size", "10").SetURLQuery("keep", "1").SetURLQueryStruct(opts)
+	if c.Error != nil {
+		t.Fatal(c.Error)
+	}
+
+	want := "active=1&filter%5Bstatus%5D=open&id=1%2C2%2C3&keep=1&key%5B%5D=x&name=foo&since=2020-01-02&size=20&sort=DESC&tag=a&tag=b&until=1600000000"
+	if got := c.URLQuery.Encode(); got != want {
+		t.Errorf("编码结果为 %s 期望 %s", got, want)
+	}
+
+	if c := New().SetURLQueryStruct(queryPage{}); c.URLQuery.Encode() != "size=0" {
+		t.Error("omitempty 未生效", c.URLQuery.Encode())
+	}
+
+	if c := New().SetURLQueryStruct("foo"); c.Error == nil {
+		t.Error("非 struct 应返回错误")
+	}
+}
+
+func TestSetURLQueryStructSlice(t *testing.T) {
+	type item struct {
+		ID   int    `url:"id"`
+		Name string `url:"name"`
+	}
+
+	c := New().SetURLQueryStruct(struct {
+		Items []item      `url:"items"`
+		Times []time.Time `url:"t,comma,unixmilli"`
+	}{
+		Items: []item{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}},
+		Times: []time.Time{time.Unix(1, 0), time.Unix(2, 0)},
+	})
+	if c.Error != nil {
+		t.Fatal(c.Error)
+	}
+
+	want := "items%5Bid%5D=1&items%5Bid%5D=2&items%5Bname%5D=a&items%5Bname%5D=b&t=1000%2C2000"
+	if got := c.URLQuery.Encode(); got != want {
+		t.Errorf("编码结果为 %s 期望 %s", got, want)
+	}
+}
@@ -8,7 +8,7 @@ import (
 )
 
 // NewRequestWithContext 新建请求
-func NewRequestWithContext(ctx context.Context, method string, url string, headers map[string]string, body io.Reader) (*http.Request, error) { // 带认证的 考虑一下怎么处理
+func NewRequestWithContext(ctx context.Context, method string, url string, headers http.Header, body io.Reader) (*http.Request, error) { // 认证请使用 Client.SetAuth 等方法
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
@@ -19,11 +19,17 @@ func NewRequestWithContext(ctx context.Context, method string, url string, heade
 		req.Header.Set("User-Agent", userAgent)
 	}
 
-	for k, v := range headers {
-		if v == "" {
+	for k, values := range headers {
+		if len(values) == 0 {
 			continue
 		}
-		req.Header.Set(k, v)
+		k = http.CanonicalHeaderKey(k)
+		req.Header.Del(k) // 覆盖环境变量设置的值
+		for _, v := range values {
+			if v != "" {
+				req.Header.Add(k, v)
+			}
+		}
 	}
 
 	return req, err
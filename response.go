@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
 )
 
@@ -12,24 +13,25 @@ type Response struct {
 	*http.Response
 }
 
-// IsSuccessFul 响应成功
+// IsSuccessful 响应成功
 func (r Response) IsSuccessful() bool {
-	return r.StatusCode > 200 && r.StatusCode < 300
+	return r.StatusCode >= 200 && r.StatusCode < 300
 }
 
-// Bytes 以 byetes 的方式显示响应
+// Bytes 以 byetes 的方式显示响应 响应不成功时返回读取到的内容以及 HTTPError
 func (r *Response) Bytes(maxSize ...int) ([]byte, error) {
 	defer r.Body.Close()
 
-	if r.StatusCode < http.StatusOK && r.StatusCode > http.StatusIMUsed {
-		return nil, errors.New("http status code unsuccessful response")
-	}
-
 	size := 64 * 1024 // 本值越大越费内存
 	if len(maxSize) != 0 && maxSize[0] > 0 {
 		size = maxSize[0]
 	}
 	body, err := readOnlySpecifiedSize(r.Body, size)
+
+	// 响应不成功时依然返回读取到的内容（便于查看错误信息）
+	if !r.IsSuccessful() {
+		return body, newHTTPError(r, body)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -54,20 +56,44 @@ func (r *Response) ToJSON(ptr interface{}, maxSize ...int) error {
 	return json.Unmarshal(body, ptr)
 }
 
+// Decode 按响应的 Content-Type 选择解码器解析响应 （不限制大小）
+func (r *Response) Decode(ptr interface{}) error {
+	if !r.IsSuccessful() {
+		return statusError(r)
+	}
+	defer r.Body.Close()
+
+	decoder, err := lookupDecoder(r.Header.Get(HeaderContentType))
+	if err != nil {
+		return err
+	}
+
+	return decoder(r.Body, ptr)
+}
+
 // readOnlySpecifiedSize 仅允许读取的尺寸（防止读巨型文件时爆内存）
 func readOnlySpecifiedSize(src io.Reader, maxSize int) ([]byte, error) {
 	cache := make([]byte, maxSize+1)
 
 	n, err := io.ReadFull(src, cache)
 	if err != nil {
-		if err != io.ErrUnexpectedEOF {
+		if err != io.ErrUnexpectedEOF && err != io.EOF {
 			return nil, err
 		}
 	}
 
 	if n > maxSize {
-		return nil, errors.New("there more data")
+		return cache[:maxSize], errors.New("there more data")
 	}
 
 	return cache[:n], nil
 }
+
+// discardBody 丢弃（少量）剩余内容并关闭 以便连接可以被复用
+func discardBody(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	io.CopyN(ioutil.Discard, body, 4*1024)
+	body.Close()
+}
@@ -0,0 +1,70 @@
+package httpc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// SetResult 请求成功（2xx）时自动按 Content-Type 将响应解析到 ptr （不限制大小）
+func (c Client) SetResult(ptr interface{}) *Client {
+	c.Result = ptr
+	return &c
+}
+
+// SetErrorResult 请求失败（非 2xx）时自动按 Content-Type 将响应解析到 ptr
+func (c Client) SetErrorResult(ptr interface{}) *Client {
+	c.ErrorResult = ptr
+	return &c
+}
+
+// decodeResult 按状态码将响应解析到 Result 或 ErrorResult
+func (c Client) decodeResult(resp *Response) error {
+	if resp.IsSuccessful() {
+		if c.Result == nil || !hasBody(resp) {
+			return nil
+		}
+		defer discardBody(resp.Body)
+
+		decoder, err := lookupDecoder(resp.Header.Get(HeaderContentType))
+		if err != nil {
+			return err
+		}
+		return decoder(resp.Body, c.Result)
+	}
+
+	if c.ErrorResult == nil || !hasBody(resp) {
+		return nil
+	}
+
+	// 错误响应的内容类型不确定（比如网关返回的 html）没有对应的解码器时不解析
+	decoder, err := lookupDecoder(resp.Header.Get(HeaderContentType))
+	if err != nil {
+		return nil
+	}
+
+	// 错误响应读取后放回 body 中 以便后续继续读取
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	return decoder(bytes.NewReader(b), c.ErrorResult)
+}
+
+// hasBody 响应是否存在 body
+func hasBody(resp *Response) bool {
+	if resp.Body == nil || resp.Body == http.NoBody || resp.ContentLength == 0 {
+		return false
+	}
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return false
+	}
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return false
+	}
+
+	return true
+}
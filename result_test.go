@@ -0,0 +1,78 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetResult(t *testing.T) {
+	name := strings.Repeat("niconiconi", 10*1024) // 超过 64KiB
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"elissa"}`))
+			return
+		}
+		w.Write([]byte(`{"name":"` + name + `"}`))
+	}))
+	defer srv.Close()
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	client := SetResult(&out).SetErrorResult(&apiErr)
+
+	if _, err := client.Get(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != name {
+		t.Error("成功响应解析失败")
+	}
+
+	res, err := client.Get(srv.URL + "/error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiErr.Message != "elissa" {
+		t.Error("失败响应解析失败")
+	}
+	if res.Text() != `{"message":"elissa"}` {
+		t.Error("失败响应解析后 body 应可再次读取")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get(HeaderAccept), MIMEApplicationXML) {
+			w.Header().Set(HeaderContentType, MIMEApplicationXML)
+			w.Write([]byte(`<user><name>niconiconi</name></user>`))
+			return
+		}
+		w.Header().Set(HeaderContentType, "application/vnd.user+json")
+		w.Write([]byte(`{"name":"niconiconi"}`))
+	}))
+	defer srv.Close()
+
+	for _, accept := range []string{MIMEApplicationXML, MIMEApplicationJSON} {
+		var out struct {
+			Name string `json:"name" xml:"name"`
+		}
+		res, err := SetAccept(accept).Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := res.Decode(&out); err != nil || out.Name != "niconiconi" {
+			t.Errorf("%s 解析失败: %v", accept, err)
+		}
+	}
+
+	if SetAccept().Headers.Get(HeaderAccept) == "" {
+		t.Error("默认 Accept 不应为空")
+	}
+}
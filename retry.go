@@ -0,0 +1,201 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultRetryStatusCodes 默认进行重试的状态码
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// Backoff 退避策略 attempt 为已经失败的次数（从 1 开始）
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff 指数退避（带抖动）
+// 等待时间为 min * 2^(attempt-1) 且不超过 max，实际等待时间在其一半到全部之间随机
+func ExponentialBackoff(min, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := min
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		if d <= 0 {
+			return 0
+		}
+
+		half := d / 2
+		return half + time.Duration(rand.Int63n(int64(d-half)+1))
+	}
+}
+
+// ConstantBackoff 固定时间退避
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// RetryPolicy 重试策略（零值字段会使用默认值）
+type RetryPolicy struct {
+	MaxAttempts int           // 最大尝试次数（包含首次请求）默认 3
+	MaxElapsed  time.Duration // 总耗时上限 0 为不限制
+	MaxWait     time.Duration // 单次等待时间上限（Retry-After 也会被限制在此范围内）默认 1 分钟
+	Backoff     Backoff       // 退避策略 默认 ExponentialBackoff(100ms, 10s)
+	StatusCodes []int         // 需要重试的状态码 默认 DefaultRetryStatusCodes
+}
+
+// SetRetry 设置重试策略（出现网络错误或指定状态码时重新发送请求）
+func (c Client) SetRetry(policy RetryPolicy) *Client {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.MaxWait <= 0 {
+		policy.MaxWait = time.Minute
+	}
+	if policy.Backoff == nil {
+		policy.Backoff = ExponentialBackoff(100*time.Millisecond, 10*time.Second)
+	}
+	if policy.StatusCodes == nil {
+		policy.StatusCodes = DefaultRetryStatusCodes
+	}
+	policy.StatusCodes = append([]int(nil), policy.StatusCodes...)
+
+	c.Retry = &policy
+	return &c
+}
+
+// DeleteRetry 删除重试策略
+func (c Client) DeleteRetry() *Client {
+	c.Retry = nil
+	return &c
+}
+
+// doWithRetry 发送请求 按重试策略进行重试
+func (c Client) doWithRetry(ctx context.Context, method string, url string, body *requestBody) (*Response, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		req, err := c.newRequest(ctx, method, url, body)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.Do(req)
+		if err != nil && req.Body != nil {
+			// 中间件、认证或签名出错时 body 没有交给 Transport 不会被关闭
+			// 需要手动关闭 否则压缩以及流式表单的写入协程会一直阻塞
+			req.Body.Close()
+		}
+		if c.Retry == nil || !c.Retry.retryable(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait, ok := c.Retry.wait(attempt, start, resp)
+		if !ok {
+			return resp, err
+		}
+		if err == nil {
+			discardBody(resp.Body)
+		}
+		if err := sleepWithContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryable 是否需要重试
+func (p *RetryPolicy) retryable(ctx context.Context, resp *Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, errNotReplayable)
+	}
+	if resp == nil || resp.Response == nil {
+		return false
+	}
+
+	for _, v := range p.StatusCodes {
+		if resp.StatusCode == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wait 计算下次重试前需要等待的时间 返回 false 则表示不应再重试
+func (p *RetryPolicy) wait(attempt int, start time.Time, resp *Response) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	d := p.Backoff(attempt)
+	if resp != nil && resp.Response != nil {
+		if after, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			d = after
+		}
+	}
+	if p.MaxWait > 0 && d > p.MaxWait {
+		d = p.MaxWait
+	}
+
+	if p.MaxElapsed > 0 && time.Since(start)+d > p.MaxElapsed {
+		return 0, false
+	}
+
+	return d, true
+}
+
+// parseRetryAfter 解析 Retry-After（支持秒数以及 http 日期）
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	d := time.Until(t)
+	if d < 0 {
+		d = 0
+	}
+
+	return d, true
+}
+
+// sleepWithContext 等待指定时间（可取消）
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
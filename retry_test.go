@@ -0,0 +1,80 @@
+package httpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetRetry(t *testing.T) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if string(b) != "niconiconi" {
+			t.Errorf("第 %d 次请求 body 不一致: %q", atomic.LoadInt32(&count)+1, b)
+		}
+		if atomic.AddInt32(&count, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	// 使用一次性的 reader 确认 body 被缓存后重放
+	res, err := SetRetry(RetryPolicy{Backoff: ConstantBackoff(time.Millisecond)}).
+		SetBody(ioutil.NopCloser(strings.NewReader("niconiconi"))).Post(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text() != "ok" || count != 3 {
+		t.Errorf("重试失败 请求次数: %d", count)
+	}
+}
+
+func TestSetRetryMaxAttempts(t *testing.T) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	res, err := SetRetry(RetryPolicy{MaxAttempts: 2, Backoff: ConstantBackoff(0)}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadGateway || count != 2 {
+		t.Errorf("最大尝试次数不生效 请求次数: %d", count)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("3"); !ok || d != 3*time.Second {
+		t.Error("解析秒数失败")
+	}
+	if d, ok := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); !ok || d <= 0 {
+		t.Error("解析日期失败")
+	}
+	if _, ok := parseRetryAfter("niconiconi"); ok {
+		t.Error("非法值应解析失败")
+	}
+}
+
+func TestRetryMaxWait(t *testing.T) {
+	policy := New().SetRetry(RetryPolicy{}).Retry
+	if policy.MaxWait != time.Minute {
+		t.Error("MaxWait 默认值错误", policy.MaxWait)
+	}
+
+	resp := &Response{Response: &http.Response{Header: http.Header{"Retry-After": {"86400"}}}}
+	if d, ok := policy.wait(1, time.Now(), resp); !ok || d != time.Minute {
+		t.Error("Retry-After 应被限制在 MaxWait 以内", d)
+	}
+}
@@ -0,0 +1,301 @@
+package httpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signer 请求签名 在请求实际发送前调用（每次重试都会重新签名）
+// 此时 URL 已经包含基础 URL 与查询参数，body 可以通过 req.GetBody 重复读取
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// SignerFunc 函数形式的 Signer
+type SignerFunc func(req *http.Request) error
+
+// Sign 实现 Signer
+func (f SignerFunc) Sign(req *http.Request) error {
+	return f(req)
+}
+
+// SetSigner 设置请求签名（body 会被缓存以便计算哈希）
+func (c Client) SetSigner(signer Signer) *Client {
+	c.Signer = signer
+	return &c
+}
+
+// DeleteSigner 删除请求签名
+func (c Client) DeleteSigner() *Client {
+	c.Signer = nil
+	return &c
+}
+
+// signHandler 签名后再发送
+func signHandler(signer Signer, next Handler) Handler {
+	return func(req *http.Request) (*Response, error) {
+		if err := signer.Sign(req); err != nil {
+			return nil, err
+		}
+		return next(req)
+	}
+}
+
+// hashBody 计算 body 的哈希 （不会消耗 req.Body）
+// 流式 body（如 SetFromDataStream）重新打开时可能会中断正在使用的读取器 所以计算完成后会换上新打开的 req.Body
+func hashBody(req *http.Request, newHash func() hash.Hash) ([]byte, error) {
+	h := newHash()
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errNotReplayable
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(h, body)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = body
+	}
+
+	return h.Sum(nil), nil
+}
+
+// rfc3986Escape 按 RFC 3986 进行编码（仅保留非保留字符）
+func rfc3986Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+
+	return b.String()
+}
+
+// canonicalPath 将路径的每一段按 RFC 3986 编码
+func canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, v := range segments {
+		if unescaped, err := url.PathUnescape(v); err == nil {
+			v = unescaped
+		}
+		segments[i] = rfc3986Escape(v)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery 按键值排序并按 RFC 3986 编码的查询参数
+func canonicalQuery(u *url.URL) string {
+	values, _ := url.ParseQuery(u.RawQuery)
+
+	var pairs []string
+	for k, vs := range values {
+		for _, v := range vs {
+			pairs = append(pairs, rfc3986Escape(k)+"="+rfc3986Escape(v))
+		}
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+// hmacSum 计算 HMAC
+func hmacSum(newHash func() hash.Hash, key []byte, data string) []byte {
+	h := hmac.New(newHash, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// HMACSigner 通用 HMAC 签名 签名内容为（以换行分隔）：
+//
+//	请求方法
+//	RFC 3986 编码的路径
+//	排序后的查询参数
+//	小写的 header 名:值 （按 SignedHeaders 的顺序 每个一行 TimestampHeader 在最后）
+//	body 哈希的 hex
+type HMACSigner struct {
+	Key             []byte              // 密钥
+	Hash            func() hash.Hash    // 哈希算法 默认 sha256
+	SignedHeaders   []string            // 参与签名的 header
+	TimestampHeader string              // 不为空时写入当前时间戳（秒）并参与签名
+	SignatureHeader string              // 签名写入的 header 默认 X-Signature
+	Encode          func([]byte) string // 签名编码方式 默认 hex
+}
+
+// StringToSign 生成待签名的字符串（服务端可用于校验）
+func (s HMACSigner) StringToSign(req *http.Request) (string, error) {
+	newHash := s.Hash
+	if newHash == nil {
+		newHash = sha256.New
+	}
+
+	bodyHash, err := hashBody(req, newHash)
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{req.Method, canonicalPath(req.URL), canonicalQuery(req.URL)}
+	headers := s.SignedHeaders
+	if s.TimestampHeader != "" {
+		headers = append(append([]string(nil), headers...), s.TimestampHeader)
+	}
+	for _, v := range headers {
+		value := req.Header.Get(v)
+		if strings.EqualFold(v, "Host") {
+			value = requestHost(req)
+		}
+		lines = append(lines, strings.ToLower(v)+":"+strings.TrimSpace(value))
+	}
+	lines = append(lines, hex.EncodeToString(bodyHash))
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// Sign 实现 Signer
+func (s HMACSigner) Sign(req *http.Request) error {
+	if s.TimestampHeader != "" {
+		req.Header.Set(s.TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	}
+
+	stringToSign, err := s.StringToSign(req)
+	if err != nil {
+		return err
+	}
+
+	newHash, encode, header := s.Hash, s.Encode, s.SignatureHeader
+	if newHash == nil {
+		newHash = sha256.New
+	}
+	if encode == nil {
+		encode = hex.EncodeToString
+	}
+	if header == "" {
+		header = "X-Signature"
+	}
+
+	req.Header.Set(header, encode(hmacSum(newHash, s.Key, stringToSign)))
+	return nil
+}
+
+// requestHost 请求的 Host
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+// SigV4Signer AWS Signature Version 4 签名 （可用于 S3 以及 MinIO 等兼容服务）
+type SigV4Signer struct {
+	AccessKeyID     string // 访问密钥 ID
+	SecretAccessKey string // 访问密钥
+	SessionToken    string // 临时凭证的会话 token （可选）
+	Region          string // 区域 如 us-east-1
+	Service         string // 服务 如 s3
+	UnsignedPayload bool   // 不对 body 签名 （S3 上传大文件时可以避免读取两次 body）
+
+	now func() time.Time // 测试用
+}
+
+// Sign 实现 Signer
+func (s SigV4Signer) Sign(req *http.Request) error {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	isS3 := s.Service == "s3"
+
+	payloadHash := "UNSIGNED-PAYLOAD"
+	if !s.UnsignedPayload {
+		sum, err := hashBody(req, sha256.New)
+		if err != nil {
+			return err
+		}
+		payloadHash = hex.EncodeToString(sum)
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	if isS3 {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	// S3 的路径只编码一次 其它服务需要编码两次
+	path := canonicalPath(req.URL)
+	req.URL.RawPath = path
+	if !isS3 {
+		path = strings.ReplaceAll(path, "%", "%25")
+	}
+	query := canonicalQuery(req.URL)
+	req.URL.RawQuery = query
+
+	headers := map[string]string{"host": requestHost(req)}
+	for k, v := range req.Header {
+		lower := strings.ToLower(k)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" {
+			values := make([]string, len(v))
+			for i := range v {
+				values[i] = strings.Join(strings.Fields(v[i]), " ")
+			}
+			headers[lower] = strings.Join(values, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, v := range names {
+		canonicalHeaders.WriteString(v + ":" + headers[v] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{req.Method, path, query, canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSum(sha256.New, []byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSum(sha256.New, key, s.Region)
+	key = hmacSum(sha256.New, key, s.Service)
+	key = hmacSum(sha256.New, key, "aws4_request")
+	signature := hex.EncodeToString(hmacSum(sha256.New, key, stringToSign))
+
+	req.Header.Set(HeaderAuthorization, "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+	return nil
+}
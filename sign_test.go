@@ -0,0 +1,123 @@
+package httpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigV4Signer(t *testing.T) {
+	// AWS Signature Version 4 测试套件中的 get-vanilla 与 get-vanilla-query-order-key-case
+	signer := SigV4Signer{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+		now:             func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+	}
+
+	cases := map[string]string{
+		"https://example.amazonaws.com/":                             "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		"https://example.amazonaws.com/?Param2=value2&Param1=value1": "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+	}
+	for url, signature := range cases {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		if err := signer.Sign(req); err != nil {
+			t.Fatal(err)
+		}
+
+		want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + signature
+		if req.Header.Get(HeaderAuthorization) != want {
+			t.Errorf("%s 签名错误: %s", url, req.Header.Get(HeaderAuthorization))
+		}
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	signer := HMACSigner{Key: []byte("niconiconi"), SignedHeaders: []string{"Host", HeaderContentType}, TimestampHeader: "X-Timestamp"}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodyHash := sha256.Sum256(b)
+		stringToSign := strings.Join([]string{
+			r.Method,
+			"/api/users",
+			"a=1&b=2%203",
+			"host:" + r.Host,
+			"content-type:" + MIMETextPlain,
+			"x-timestamp:" + r.Header.Get("X-Timestamp"),
+			hex.EncodeToString(bodyHash[:]),
+		}, "\n")
+
+		mac := hmac.New(sha256.New, []byte("niconiconi"))
+		mac.Write([]byte(stringToSign))
+		if r.Header.Get("X-Signature") != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	res, err := SetBaseURL(srv.URL+"/api").SetPreserveBasePath(true).SetURLQuery("b", "2 3").SetURLQuery("a", "1").SetContentType(MIMETextPlain).
+		SetBody(ioutil.NopCloser(strings.NewReader("niconiconi"))).SetSigner(signer).Post("/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Error("HMAC 签名校验失败")
+	}
+}
+
+func TestHMACSignerStream(t *testing.T) {
+	data := strings.Repeat("niconiconi", 64*1024)
+	path := filepath.Join(t.TempDir(), "data")
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodyHash := sha256.Sum256(b)
+		stringToSign := strings.Join([]string{r.Method, "/", "", hex.EncodeToString(bodyHash[:])}, "\n")
+
+		mac := hmac.New(sha256.New, []byte("niconiconi"))
+		mac.Write([]byte(stringToSign))
+		if r.Header.Get("X-Signature") != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if !strings.Contains(string(b), data) {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	// 计算哈希时重新打开 body 不应影响实际发送的 body
+	rows := map[string]FromDataRow{
+		"strings.Reader": {Key: "file", Value: "data", Data: strings.NewReader(data)},
+		"os.File":        {Key: "file", Value: "data", Data: f},
+	}
+	for name, row := range rows {
+		res, err := SetFromDataStream(row).SetSigner(HMACSigner{Key: []byte("niconiconi")}).Post(srv.URL)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Error(name, "流式 body 签名失败", res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package httpc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SetPathParam 设置路径参数 请求地址（以及基础 URL）中的 {key} 会被替换为编码后的值
+// 支持 RFC 6570 URI 模板 如 /users/{id}{?page,size}
+func (c Client) SetPathParam(key, value string) *Client {
+	return c.SetPathParams(map[string]string{key: value})
+}
+
+// SetPathParams 设置多个路径参数
+func (c Client) SetPathParams(params map[string]string) *Client {
+	out := make(map[string]string, len(c.PathParams)+len(params))
+	for k, v := range c.PathParams {
+		out[k] = v
+	}
+	for k, v := range params {
+		out[k] = v
+	}
+
+	c.PathParams = out
+	return &c
+}
+
+// DeletePathParams 删除所有路径参数
+func (c Client) DeletePathParams() *Client {
+	c.PathParams = nil
+	return &c
+}
+
+// templateOperator RFC 6570 表达式操作符
+type templateOperator struct {
+	first         string // 展开结果的前缀
+	sep           string // 多个变量之间的分隔符
+	named         bool   // 是否输出 name=
+	ifEmpty       string // 值为空时 name 后面的内容
+	allowReserved bool   // 是否保留保留字符
+	optional      bool   // 变量未定义时是否忽略（查询表达式）
+}
+
+var templateOperators = map[byte]templateOperator{
+	'+': {first: "", sep: ",", allowReserved: true},
+	'#': {first: "#", sep: ",", allowReserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "=", optional: true},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "=", optional: true},
+}
+
+// expandURITemplate 按 RFC 6570 展开 URI 模板
+// 路径表达式引用未定义的变量时返回错误（防止请求到错误的资源），查询表达式（{?x} {&x}）中未定义的变量会被忽略
+// 字面的查询参数以及片段（? # 之后）中只展开查询表达式 其它花括号原样保留（如 JSON 格式的过滤条件）
+func expandURITemplate(template string, vars map[string]string) (string, error) {
+	var out strings.Builder
+	literal := false
+	for i := 0; i < len(template); {
+		c := template[i]
+		if c != '{' {
+			if c == '?' || c == '#' {
+				literal = true
+			}
+			out.WriteByte(c)
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(template[i:], '}')
+		if literal && (end < 0 || i+1 >= len(template) || (template[i+1] != '?' && template[i+1] != '&')) {
+			out.WriteByte(c)
+			i++
+			continue
+		}
+		if end < 0 {
+			return "", fmt.Errorf("uri template: unclosed expression in %q", template)
+		}
+
+		if err := expandExpression(&out, template[i+1:i+end], vars); err != nil {
+			return "", err
+		}
+		i += end + 1
+	}
+
+	return out.String(), nil
+}
+
+// expandExpression 展开单个表达式
+func expandExpression(out *strings.Builder, expr string, vars map[string]string) error {
+	if expr == "" {
+		return fmt.Errorf("uri template: empty expression")
+	}
+
+	op, ok := templateOperators[expr[0]]
+	if ok {
+		expr = expr[1:]
+	} else {
+		op = templateOperator{sep: ","}
+	}
+
+	first := true
+	for _, spec := range strings.Split(expr, ",") {
+		name := strings.TrimSuffix(spec, "*") // 变量都是字符串 展开修饰符没有区别
+		prefix := -1
+		if i := strings.IndexByte(name, ':'); i >= 0 {
+			n, err := strconv.Atoi(name[i+1:])
+			if err != nil || n <= 0 || n >= 10000 {
+				return fmt.Errorf("uri template: invalid prefix modifier in %q", spec)
+			}
+			name, prefix = name[:i], n
+		}
+		if name == "" {
+			return fmt.Errorf("uri template: invalid variable %q", spec)
+		}
+
+		value, ok := vars[name]
+		if !ok {
+			if op.optional {
+				continue
+			}
+			return fmt.Errorf("uri template: undefined variable %q", name)
+		}
+		if prefix >= 0 {
+			if runes := []rune(value); len(runes) > prefix {
+				value = string(runes[:prefix])
+			}
+		}
+
+		if first {
+			out.WriteString(op.first)
+			first = false
+		} else {
+			out.WriteString(op.sep)
+		}
+
+		if op.named {
+			out.WriteString(templateEscape(name, true))
+			if value == "" {
+				out.WriteString(op.ifEmpty)
+				continue
+			}
+			out.WriteString("=")
+		}
+		out.WriteString(templateEscape(value, op.allowReserved))
+	}
+
+	return nil
+}
+
+// templateEscape 编码变量值 allowReserved 为 true 时保留保留字符以及已编码的内容
+func templateEscape(s string, allowReserved bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case allowReserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case allowReserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
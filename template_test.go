@@ -0,0 +1,72 @@
+package httpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExpandURITemplate(t *testing.T) {
+	// RFC 6570 中的示例
+	vars := map[string]string{"var": "value", "hello": "Hello World!", "path": "/foo/bar", "empty": "", "x": "1024", "y": "768"}
+	cases := map[string]string{
+		"{var}":               "value",
+		"{hello}":             "Hello%20World%21",
+		"{+hello}":            "Hello%20World!",
+		"{+path}/here":        "/foo/bar/here",
+		"{#path,x}/here":      "#/foo/bar,1024/here",
+		"X{.var}":             "X.value",
+		"{/var,x}/here":       "/value/1024/here",
+		"{;x,y,empty}":        ";x=1024;y=768;empty",
+		"{?x,y,empty}":        "?x=1024&y=768&empty=",
+		"?fixed=yes{&x}":      "?fixed=yes&x=1024",
+		"{var:3}":             "val",
+		"{?undef}":            "",
+		"/users/{path}/posts": "/users/%2Ffoo%2Fbar/posts",
+		`/{x}?filter={"a":1}`: `/1024?filter={"a":1}`,
+		`/{x}?q={&y}`:         `/1024?q=&y=768`,
+		"/{x}#{var}":          "/1024#{var}",
+	}
+
+	for template, want := range cases {
+		got, err := expandURITemplate(template, vars)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s 展开为 %s 期望 %s", template, got, want)
+		}
+	}
+
+	if _, err := expandURITemplate("/users/{id", vars); err == nil {
+		t.Error("未闭合的表达式应返回错误")
+	}
+	if _, err := expandURITemplate("/users/{undef}", vars); err == nil {
+		t.Error("路径表达式引用未定义的变量应返回错误")
+	}
+}
+
+func TestSetPathParam(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+	defer srv.Close()
+
+	res, err := SetBaseURL(srv.URL+"/{version}").SetPreserveBasePath(true).SetPathParam("version", "v1").
+		SetPathParams(map[string]string{"id": "4 2", "page": "2"}).Get("/users/{id}/posts{?page}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Text(); text != "/v1/users/4%202/posts?page=2" {
+		t.Errorf("路径参数展开错误: %s", text)
+	}
+
+	// 没有设置任何路径参数时同样应返回错误 而不是原样发送
+	if _, err := Get(srv.URL + "/users/{id}"); err == nil {
+		t.Error("路径中引用未设置的变量应返回错误")
+	}
+	if res, err := Get(srv.URL + "/users{?page}"); err != nil || res.Text() != "/users" {
+		t.Error("未设置的查询变量应被忽略", err)
+	}
+}
@@ -0,0 +1,96 @@
+package httpc
+
+import (
+	"fmt"
+	stdURL "net/url"
+	"strings"
+)
+
+// SetPreserveBasePath 解析相对地址时保留基础 URL 的路径
+// 默认按 RFC 3986 解析 以 / 开头的地址会替换整个路径（https://api/v1 + /users = https://api/users）
+// 保留时基础 URL 的路径视为目录（https://api/v1 + /users = https://api/v1/users）
+func (c Client) SetPreserveBasePath(preserve bool) *Client {
+	c.PreserveBasePath = preserve
+	return &c
+}
+
+// parseBaseURL 校验基础 URL
+func parseBaseURL(url string) (*stdURL.URL, error) {
+	base, err := stdURL.Parse(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if !base.IsAbs() || base.Host == "" {
+		return nil, fmt.Errorf("invalid base url %q: scheme and host are required", url)
+	}
+
+	return base, nil
+}
+
+// buildURL 展开路径参数 使用基础 URL 解析地址并合并查询参数
+// 未设置路径参数时同样会展开 （路径中引用未设置的变量时返回错误）
+func (c Client) buildURL(url string) (string, error) {
+	var err error
+	if url, err = expandURITemplate(url, c.PathParams); err != nil {
+		return "", err
+	}
+	if c.BaseURL, err = expandURITemplate(c.BaseURL, c.PathParams); err != nil {
+		return "", err
+	}
+
+	ref, err := stdURL.Parse(url)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+
+	// 绝对地址直接使用
+	target := ref
+	if !ref.IsAbs() && c.BaseURL != "" {
+		base, err := parseBaseURL(c.BaseURL)
+		if err != nil {
+			return "", err
+		}
+		target = resolveReference(base, ref, c.PreserveBasePath)
+	}
+
+	if !target.IsAbs() || target.Host == "" {
+		return "", fmt.Errorf("invalid url %q: scheme and host are required", url)
+	}
+
+	if c.URLQuery != nil {
+		withQuery := target.Query()
+		for k, v := range c.URLQuery {
+			for _, j := range v {
+				withQuery.Add(k, j)
+			}
+		}
+		target.RawQuery = withQuery.Encode()
+	}
+
+	return target.String(), nil
+}
+
+// resolveReference 按 RFC 3986 解析相对地址 preservePath 为 true 时基础 URL 的路径视为目录并保留
+func resolveReference(base, ref *stdURL.URL, preservePath bool) *stdURL.URL {
+	// 网络路径（//host/path）以及空路径（仅查询参数或片段）按规范处理
+	if !preservePath || ref.Host != "" || (ref.Path == "" && ref.RawPath == "") {
+		return base.ResolveReference(ref)
+	}
+
+	dir := *base
+	if !strings.HasSuffix(dir.Path, "/") {
+		dir.Path += "/"
+		if dir.RawPath != "" {
+			dir.RawPath += "/"
+		}
+	}
+
+	rel := *ref
+	rel.Path = strings.TrimLeft(rel.Path, "/")
+	rel.RawPath = strings.TrimLeft(rel.RawPath, "/")
+	if rel.Path == "" { // 仅有 / 时指向基础路径本身
+		rel.Path = "./"
+	}
+
+	return dir.ResolveReference(&rel)
+}